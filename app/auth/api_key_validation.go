@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"wrench/app"
+	"wrench/app/contexts"
+	"wrench/app/cross_funcs"
+	"wrench/app/manifest/api_settings"
+	"wrench/app/startup/connections"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var apiKeyConsumers = make(map[*api_settings.ApiKeyAuthorizationSettings]map[string]*contexts.Consumer)
+var apiKeyConsumersMutex sync.Mutex
+
+// ApiKeyValidate returns the consumer owning the request api key or nil when the key is unknown.
+func ApiKeyValidate(ctx context.Context, wrenchContext *contexts.WrenchContext, apiKeySettings *api_settings.ApiKeyAuthorizationSettings) (*contexts.Consumer, error) {
+	apiKey := getRequestApiKey(wrenchContext, apiKeySettings)
+	if len(apiKey) == 0 {
+		return nil, nil
+	}
+
+	keyHash := hashApiKey(apiKey)
+
+	// keys are compared by their digest, so the lookup doesn't leak the configured value through timing
+	if consumer := getConfiguredApiKeyConsumers(apiKeySettings)[keyHash]; consumer != nil {
+		return consumer, nil
+	}
+
+	if apiKeySettings.Redis != nil {
+		return getRedisApiKeyConsumer(ctx, apiKeySettings.Redis, keyHash)
+	}
+
+	return nil, nil
+}
+
+func ConsumerRolesValidation(consumer *contexts.Consumer, roles []string) bool {
+	for _, role := range roles {
+		if !cross_funcs.ArrayStringContains(consumer.Roles, role) {
+			app.LogWarning(fmt.Sprintf("Roles %v is required", role))
+			return false
+		}
+	}
+
+	return true
+}
+
+func getRequestApiKey(wrenchContext *contexts.WrenchContext, apiKeySettings *api_settings.ApiKeyAuthorizationSettings) string {
+	if headerName := apiKeySettings.GetHeaderName(); len(headerName) > 0 {
+		if apiKey := wrenchContext.Request.Header.Get(headerName); len(apiKey) > 0 {
+			return apiKey
+		}
+	}
+
+	if len(apiKeySettings.QueryParameter) > 0 {
+		return wrenchContext.Request.URL.Query().Get(apiKeySettings.QueryParameter)
+	}
+
+	return ""
+}
+
+func hashApiKey(apiKey string) string {
+	hash := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(hash[:])
+}
+
+func getConfiguredApiKeyConsumers(apiKeySettings *api_settings.ApiKeyAuthorizationSettings) map[string]*contexts.Consumer {
+	apiKeyConsumersMutex.Lock()
+	defer apiKeyConsumersMutex.Unlock()
+
+	consumers := apiKeyConsumers[apiKeySettings]
+	if consumers == nil {
+		consumers = make(map[string]*contexts.Consumer)
+
+		for _, key := range apiKeySettings.Keys {
+			consumers[hashApiKey(key.Key)] = &contexts.Consumer{
+				Id:            key.ConsumerId,
+				Roles:         key.Roles,
+				RateLimitTier: key.RateLimitTier,
+			}
+		}
+
+		apiKeyConsumers[apiKeySettings] = consumers
+	}
+
+	return consumers
+}
+
+func getRedisApiKeyConsumer(ctx context.Context, redisSettings *api_settings.ApiKeyRedisSettings, keyHash string) (*contexts.Consumer, error) {
+	uClient, err := connections.GetRedisConnection(redisSettings.RedisConnectionId)
+	if err != nil {
+		return nil, err
+	}
+
+	isMember, err := uClient.SIsMember(ctx, redisSettings.SetKey, keyHash).Result()
+	if err != nil || !isMember {
+		return nil, err
+	}
+
+	consumer := &contexts.Consumer{Id: keyHash}
+
+	consumerJson, err := uClient.HGet(ctx, redisSettings.SetKey+":consumers", keyHash).Result()
+	if err == redis.Nil {
+		return consumer, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(consumerJson), consumer); err != nil {
+		return nil, err
+	}
+
+	return consumer, nil
+}
@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"sync"
+	"wrench/app/manifest/api_settings"
+)
+
+// hmacKeys holds the keys rotated after the startup, the handlers keep the settings loaded on startup.
+var hmacKeys = make(map[string]string)
+var hmacKeysMutex sync.RWMutex
+
+func SetHmacKey(authorizationId string, key string) {
+	hmacKeysMutex.Lock()
+	defer hmacKeysMutex.Unlock()
+
+	hmacKeys[authorizationId] = key
+}
+
+func getHmacKey(authorizationSettings *api_settings.AuthorizationSettings) string {
+	hmacKeysMutex.RLock()
+	defer hmacKeysMutex.RUnlock()
+
+	if key, ok := hmacKeys[authorizationSettings.Id]; ok {
+		return key
+	}
+	return authorizationSettings.Key
+}
@@ -1,23 +1,161 @@
 package auth
 
 import (
+	"context"
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"wrench/app"
 	"wrench/app/contexts"
 	"wrench/app/cross_funcs"
 	"wrench/app/manifest/api_settings"
+	"wrench/app/stores"
 )
 
-func HMACValidate(wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext, authorizationSettings *api_settings.AuthorizationSettings) bool {
+func HMACValidate(ctx context.Context, wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext, authorizationSettings *api_settings.AuthorizationSettings) bool {
+	hmacSettings := authorizationSettings.Hmac
+	signatureValue := fmt.Sprint(contexts.GetCalculatedValue(authorizationSettings.SignatureRef, wrenchContext, bodyContext, nil))
+
+	timestamp, signatures := parseHmacSignature(hmacSettings, signatureValue)
+	if hmacSettings.HasTimestamp() {
+		if len(hmacSettings.TimestampRef) > 0 {
+			timestamp = fmt.Sprint(contexts.GetCalculatedValue(hmacSettings.TimestampRef, wrenchContext, bodyContext, nil))
+		}
+
+		if !hmacTimestampValidation(timestamp, hmacSettings.GetTolerance()) {
+			app.LogWarning(fmt.Sprintf("hmac %v timestamp %v is outside of the tolerance", authorizationSettings.Id, timestamp))
+			return false
+		}
+	}
+
 	var data = ""
 
+	if hmacSettings != nil && hmacSettings.SignedTimestamp {
+		data = timestamp + "."
+	}
+
 	for _, item := range authorizationSettings.ConcatenateFields {
 		data += fmt.Sprint(contexts.GetCalculatedValue(item, wrenchContext, bodyContext, nil))
 	}
 
-	expectedHash := contexts.GetCalculatedValue(authorizationSettings.SignatureRef, wrenchContext, bodyContext, nil)
-
 	hashFn := cross_funcs.GetHashFunc(authorizationSettings.Algorithm)
-	actualHash := cross_funcs.GetHash(authorizationSettings.Key, hashFn, []byte(data))
+	if hashFn == nil {
+		return false
+	}
+
+	mac := hmac.New(hashFn, []byte(getHmacKey(authorizationSettings)))
+	mac.Write([]byte(data))
+	expectedMAC := mac.Sum(nil)
+
+	signatureValid := false
+	for _, signature := range signatures {
+		// keep comparing every signature to not leak which one matched
+		if hmac.Equal(signature, expectedMAC) {
+			signatureValid = true
+		}
+	}
+
+	if !signatureValid {
+		return false
+	}
+
+	if hmacSettings != nil && len(hmacSettings.NonceRef) > 0 {
+		nonce := fmt.Sprint(contexts.GetCalculatedValue(hmacSettings.NonceRef, wrenchContext, bodyContext, nil))
+		return hmacNonceValidation(ctx, authorizationSettings, nonce)
+	}
+
+	return true
+}
+
+// parseHmacSignature returns the timestamp informed in the signature (keyValue format) and the decoded signatures.
+func parseHmacSignature(hmacSettings *api_settings.HmacSettings, signatureValue string) (string, [][]byte) {
+	var timestamp string
+	var signatures [][]byte
+
+	switch hmacSettings.GetSignatureFormat() {
+	case api_settings.HmacSignatureFormatBase64:
+		if signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signatureValue)); err == nil {
+			signatures = append(signatures, signature)
+		}
+	case api_settings.HmacSignatureFormatKeyValue:
+		for _, pair := range strings.Split(signatureValue, ",") {
+			pairSplitted := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+			if len(pairSplitted) != 2 {
+				continue
+			}
+
+			if pairSplitted[0] == hmacSettings.GetTimestampKey() {
+				timestamp = pairSplitted[1]
+			} else if pairSplitted[0] == hmacSettings.GetSignatureKey() {
+				if signature, err := hex.DecodeString(pairSplitted[1]); err == nil {
+					signatures = append(signatures, signature)
+				}
+			}
+		}
+	default:
+		if signature, err := hex.DecodeString(strings.TrimSpace(signatureValue)); err == nil {
+			signatures = append(signatures, signature)
+		}
+	}
+
+	return timestamp, signatures
+}
+
+// hmacTimestampValidation accepts unix seconds, unix milliseconds, RFC 3339 or HTTP dates.
+func hmacTimestampValidation(timestamp string, tolerance time.Duration) bool {
+	timestamp = strings.TrimSpace(timestamp)
+	if len(timestamp) == 0 {
+		return false
+	}
+
+	var signedAt time.Time
+	if unix, err := strconv.ParseInt(timestamp, 10, 64); err == nil {
+		if unix > 1e12 {
+			signedAt = time.UnixMilli(unix)
+		} else {
+			signedAt = time.Unix(unix, 0)
+		}
+	} else if parsed, err := time.Parse(time.RFC3339, timestamp); err == nil {
+		signedAt = parsed
+	} else if parsed, err := http.ParseTime(timestamp); err == nil {
+		signedAt = parsed
+	} else {
+		return false
+	}
+
+	return math.Abs(float64(time.Since(signedAt))) <= float64(tolerance)
+}
+
+func hmacNonceValidation(ctx context.Context, authorizationSettings *api_settings.AuthorizationSettings, nonce string) bool {
+	hmacSettings := authorizationSettings.Hmac
+
+	if len(nonce) == 0 {
+		app.LogWarning(fmt.Sprintf("hmac %v nonce is required", authorizationSettings.Id))
+		return false
+	}
+
+	store, err := stores.GetKeyValueStore("hmacNonces:"+authorizationSettings.Id, hmacSettings.GetNonceBackend(), hmacSettings.RedisConnectionId, hmacSettings.MaxEntries)
+	if err != nil {
+		app.LogError2(fmt.Sprintf("hmac %v nonce store unavailable", authorizationSettings.Id), err)
+		return false
+	}
+
+	nonceKey := fmt.Sprintf("hmac:%v:nonce:%v", authorizationSettings.Id, nonce)
+	stored, err := store.SetNX(ctx, nonceKey, []byte("1"), hmacSettings.GetNonceTtl())
+	if err != nil {
+		app.LogError2(fmt.Sprintf("hmac %v error to store nonce", authorizationSettings.Id), err)
+		return false
+	}
+
+	if !stored {
+		app.LogWarning(fmt.Sprintf("hmac %v nonce %v was already used", authorizationSettings.Id, nonce))
+	}
 
-	return expectedHash == actualHash
+	return stored
 }
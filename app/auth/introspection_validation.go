@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+	client "wrench/app/clients/http"
+	"wrench/app/manifest/api_settings"
+	"wrench/app/manifest/types"
+	"wrench/app/manifest_cross_funcs"
+	"wrench/app/stores"
+)
+
+// IntrospectionValidation calls the RFC 7662 endpoint and returns the token claims when it is active,
+// or nil when it isn't. Active results are kept in memory until the token exp.
+func IntrospectionValidation(ctx context.Context, tokenString string, introspectionSettings *api_settings.IntrospectionSettings) (map[string]interface{}, error) {
+	store, err := stores.GetKeyValueStore("introspection:"+introspectionSettings.Endpoint, types.BackendTypeMemory, "", introspectionSettings.MaxEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenHash := sha256.Sum256([]byte(tokenString))
+	cacheKey := hex.EncodeToString(tokenHash[:])
+
+	if cached, found, _ := store.Get(ctx, cacheKey); found {
+		var claims map[string]interface{}
+		if err := json.Unmarshal(cached, &claims); err == nil {
+			return claims, nil
+		}
+	}
+
+	claims, body, err := introspect(ctx, tokenString, introspectionSettings)
+	if err != nil || claims == nil {
+		return nil, err
+	}
+
+	if exp, ok := claims["exp"].(float64); ok {
+		if ttl := time.Until(time.Unix(int64(exp), 0)); ttl > 0 {
+			store.Set(ctx, cacheKey, body, ttl)
+		}
+	}
+
+	return claims, nil
+}
+
+func introspect(ctx context.Context, tokenString string, introspectionSettings *api_settings.IntrospectionSettings) (map[string]interface{}, []byte, error) {
+	tokenCredential, err := manifest_cross_funcs.GetTokenCredentialSettingById(introspectionSettings.TokenCredentialId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var username, password string
+	if tokenCredential.ClientCredential != nil {
+		username = tokenCredential.ClientCredential.ClientId
+		password = tokenCredential.ClientCredential.ClientSecret
+	} else if tokenCredential.Basic != nil {
+		username = tokenCredential.Basic.Username
+		password = tokenCredential.Basic.Password
+	}
+
+	data := url.Values{}
+	data.Set("token", tokenString)
+	if len(introspectionSettings.TokenTypeHint) > 0 {
+		data.Set("token_type_hint", introspectionSettings.TokenTypeHint)
+	}
+
+	request := new(client.HttpClientRequestData)
+	request.Body = []byte(data.Encode())
+	request.Method = "POST"
+	request.Url = introspectionSettings.Endpoint
+
+	credential := fmt.Sprintf("%s:%s", url.QueryEscape(username), url.QueryEscape(password))
+	request.SetHeader("Content-Type", "application/x-www-form-urlencoded")
+	request.SetHeader("Accept", "application/json")
+	request.SetHeader("Authorization", fmt.Sprintf("Basic %s", base64.StdEncoding.EncodeToString([]byte(credential))))
+
+	response, err := client.HttpClientDo(ctx, request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !response.StatusCodeSuccess() {
+		return nil, nil, fmt.Errorf("introspection endpoint response_status_code: %v", response.StatusCode)
+	}
+
+	var claims map[string]interface{}
+	if err := json.Unmarshal(response.Body, &claims); err != nil {
+		return nil, nil, err
+	}
+
+	if active, _ := claims["active"].(bool); !active {
+		return nil, nil, nil
+	}
+
+	return claims, response.Body, nil
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+	"wrench/app/manifest/api_settings"
+
+	"github.com/MicahParks/keyfunc"
+)
+
+// loadGivenKeys parses the static public keys configured for a jwks authorization.
+func loadGivenKeys(publicKeys []*api_settings.JwtPublicKeySettings) (map[string]keyfunc.GivenKey, error) {
+	givenKeys := make(map[string]keyfunc.GivenKey)
+
+	for _, publicKey := range publicKeys {
+		content := publicKey.Pem
+		isJwk := len(publicKey.Jwk) > 0
+
+		if isJwk {
+			content = publicKey.Jwk
+		} else if len(publicKey.File) > 0 {
+			fileContent, err := os.ReadFile(publicKey.File)
+			if err != nil {
+				return nil, err
+			}
+			content = string(fileContent)
+			isJwk = strings.HasPrefix(strings.TrimSpace(content), "{")
+		}
+
+		if isJwk {
+			jwkKeys, err := parseJwkGivenKeys(content)
+			if err != nil {
+				return nil, err
+			}
+
+			for kid, givenKey := range jwkKeys {
+				if len(publicKey.Kid) > 0 && len(jwkKeys) == 1 {
+					kid = publicKey.Kid
+				}
+				givenKeys[kid] = givenKey
+			}
+			continue
+		}
+
+		if len(publicKey.Kid) == 0 {
+			return nil, fmt.Errorf("public key file %v requires kid", publicKey.File)
+		}
+
+		givenKey, err := parsePemGivenKey(content)
+		if err != nil {
+			return nil, fmt.Errorf("public key %v: %w", publicKey.Kid, err)
+		}
+		givenKeys[publicKey.Kid] = givenKey
+	}
+
+	return givenKeys, nil
+}
+
+// parseJwkGivenKeys accepts a single JWK or a JWKS document.
+func parseJwkGivenKeys(content string) (map[string]keyfunc.GivenKey, error) {
+	var document map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &document); err != nil {
+		return nil, err
+	}
+
+	if _, isSet := document["keys"]; !isSet {
+		jwksBytes, _ := json.Marshal(map[string]interface{}{"keys": []interface{}{document}})
+		content = string(jwksBytes)
+	}
+
+	return keyfunc.NewGivenKeysFromJSON(json.RawMessage(content))
+}
+
+func parsePemGivenKey(content string) (keyfunc.GivenKey, error) {
+	block, _ := pem.Decode([]byte(content))
+	if block == nil {
+		return keyfunc.GivenKey{}, fmt.Errorf("invalid pem")
+	}
+
+	var publicKey interface{}
+	var err error
+
+	switch block.Type {
+	case "CERTIFICATE":
+		var certificate *x509.Certificate
+		certificate, err = x509.ParseCertificate(block.Bytes)
+		if err == nil {
+			publicKey = certificate.PublicKey
+		}
+	case "RSA PUBLIC KEY":
+		publicKey, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		publicKey, err = x509.ParsePKIXPublicKey(block.Bytes)
+	}
+
+	if err != nil {
+		return keyfunc.GivenKey{}, err
+	}
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		return keyfunc.NewGivenRSA(key), nil
+	case *ecdsa.PublicKey:
+		return keyfunc.NewGivenECDSA(key), nil
+	case ed25519.PublicKey:
+		return keyfunc.NewGivenEdDSA(key), nil
+	}
+
+	return keyfunc.GivenKey{}, fmt.Errorf("unsupported public key type %T", publicKey)
+}
@@ -2,23 +2,42 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 	"wrench/app"
 	auth_jwt "wrench/app/auth/jwt"
 	"wrench/app/cross_funcs"
+	"wrench/app/json_map"
 	"wrench/app/manifest/api_settings"
 
 	"github.com/MicahParks/keyfunc"
 	"github.com/golang-jwt/jwt/v4"
 )
 
-var jwksData *keyfunc.JWKS
+const jwksRetryMinDelay = time.Second
+const jwksRetryMaxDelay = time.Minute
 
-func JwksValidationAuthorization(tokenString string, roles []string, scopes []string, claims []string) bool {
-	var rolesValid, scopesValid, claimsValid bool = true, true, true
+// jwksEntry loads the JWKS of one authorization, a slow issuer only blocks its own requests
+type jwksEntry struct {
+	jwks     atomic.Pointer[keyfunc.JWKS]
+	mutex    sync.Mutex
+	failures int
+	retryAt  time.Time
+}
+
+var jwksEntries = make(map[*api_settings.AuthorizationSettings]*jwksEntry)
+var jwksEntriesMutex sync.Mutex
 
+func JwksValidationAuthorization(tokenString string, authorizationSettings *api_settings.AuthorizationSettings, endpointSettings *api_settings.EndpointSettings) bool {
 	tokenSplitted := strings.Split(tokenString, ".")
+	if len(tokenSplitted) < 2 {
+		return false
+	}
 	tokenPayload := tokenSplitted[1]
 
 	tokenPayloadMap := auth_jwt.ConvertJwtPayloadBase64ToJwtPaylodData(tokenPayload)
@@ -26,118 +45,324 @@ func JwksValidationAuthorization(tokenString string, roles []string, scopes []st
 		return false
 	}
 
-	if len(roles) > 0 {
-		rolesValid = rolesValidation(tokenPayloadMap, roles)
+	return ClaimsValidationAuthorization(tokenPayloadMap, authorizationSettings, endpointSettings)
+}
+
+// ClaimsValidationAuthorization checks the endpoint roles, scopes and claims against an already decoded token payload.
+func ClaimsValidationAuthorization(tokenPayloadMap map[string]interface{}, authorizationSettings *api_settings.AuthorizationSettings, endpointSettings *api_settings.EndpointSettings) bool {
+	var rolesValid, scopesValid, claimsValid, claimRulesValid bool = true, true, true, true
+
+	if len(endpointSettings.Roles) > 0 {
+		rolesValid = rolesValidation(tokenPayloadMap, authorizationSettings.ClaimPaths.GetRoles(), endpointSettings.Roles)
 	}
 
-	if len(scopes) > 0 {
-		scopesValid = scopesVadalition(tokenPayloadMap, scopes)
+	if len(endpointSettings.Scopes) > 0 {
+		scopesValid = scopesValidation(tokenPayloadMap, authorizationSettings.ClaimPaths.GetScopes(), endpointSettings.Scopes)
 	}
 
-	if len(claims) > 0 {
-		claimsValid = claimsValidation(tokenPayloadMap, claims)
+	if len(endpointSettings.Claims) > 0 {
+		claimsValid = claimsValidation(tokenPayloadMap, endpointSettings.Claims)
 	}
 
-	return rolesValid && scopesValid && claimsValid
+	if len(endpointSettings.ClaimRules) > 0 {
+		claimRulesValid = claimRulesValidation(tokenPayloadMap, endpointSettings.ClaimRules)
+	}
+
+	return rolesValid && scopesValid && claimsValid && claimRulesValid
 }
 
-func rolesValidation(tokenPayloadMap map[string]interface{}, roles []string) bool {
-	rolesParsed, ok := tokenPayloadMap["roles"].([]interface{})
-	result := false
+// getClaimValues reads the claim in path as a list of strings, splitting space separated strings
+// when splitSpaces is set (scope and scp are space delimited).
+func getClaimValues(tokenPayloadMap map[string]interface{}, path string, splitSpaces bool) []string {
+	value, _ := json_map.GetValue(tokenPayloadMap, path, false)
 
-	if ok {
-		rolesParsedLen := len(rolesParsed)
-		rolesParsedString := make([]string, rolesParsedLen)
-		if rolesParsedLen > 0 {
-			for index, roleParsed := range rolesParsed {
-				roleParsedStringValue, ok := roleParsed.(string)
-				if ok {
-					rolesParsedString[index] = roleParsedStringValue
-				}
-			}
+	switch claimValue := value.(type) {
+	case string:
+		if splitSpaces {
+			return strings.Fields(claimValue)
 		}
-
-		for _, role := range roles {
-			if !cross_funcs.ArrayStringContains(rolesParsedString, role) {
-				app.LogWarning(fmt.Sprintf("Roles %v is required", role))
-				return false
+		return []string{claimValue}
+	case []interface{}:
+		values := make([]string, 0, len(claimValue))
+		for _, item := range claimValue {
+			if item != nil {
+				values = append(values, fmt.Sprint(item))
 			}
 		}
+		return values
+	case nil:
+		return nil
+	case map[string]interface{}:
+		return nil
+	default:
+		return []string{fmt.Sprint(claimValue)}
+	}
+}
 
-		result = true
+func getClaimValuesFromPaths(tokenPayloadMap map[string]interface{}, paths []string, splitSpaces bool) []string {
+	var values []string
+	for _, path := range paths {
+		values = append(values, getClaimValues(tokenPayloadMap, path, splitSpaces)...)
 	}
-	return result
+	return values
 }
 
-func scopesVadalition(tokenPayloadMap map[string]interface{}, scopes []string) bool {
-	scopeParsed, ok := tokenPayloadMap["scope"].(string)
-	result := true
-	if ok {
-		for _, scope := range scopes {
+func rolesValidation(tokenPayloadMap map[string]interface{}, rolePaths []string, roles []string) bool {
+	tokenRoles := getClaimValuesFromPaths(tokenPayloadMap, rolePaths, false)
 
-			if !strings.Contains(scopeParsed, scope) {
-				result = false
-				app.LogWarning(fmt.Sprintf("scope %s is required", scope))
-				break
-			}
+	for _, role := range roles {
+		if !cross_funcs.ArrayStringContains(tokenRoles, role) {
+			app.LogWarning(fmt.Sprintf("Roles %v is required", role))
+			return false
 		}
-	} else {
-		result = false
 	}
 
-	return result
+	return true
 }
 
-func claimsValidation(tokenPayloadMap map[string]interface{}, claims []string) bool {
-	result := true
+func scopesValidation(tokenPayloadMap map[string]interface{}, scopePaths []string, scopes []string) bool {
+	tokenScopes := getClaimValuesFromPaths(tokenPayloadMap, scopePaths, true)
 
+	for _, scope := range scopes {
+		if !cross_funcs.ArrayStringContains(tokenScopes, scope) {
+			app.LogWarning(fmt.Sprintf("scope %s is required", scope))
+			return false
+		}
+	}
+
+	return true
+}
+
+// claimsValidation keeps the "path:value" syntax, array claims match when they contain the value.
+func claimsValidation(tokenPayloadMap map[string]interface{}, claims []string) bool {
 	for _, claim := range claims {
-		claimSplitted := strings.Split(claim, ":")
+		claimSplitted := strings.SplitN(claim, ":", 2)
 		claimName := claimSplitted[0]
-		claimValue := claimSplitted[1]
-		claimTokenValue, ok := tokenPayloadMap[claimName].(string)
+		var claimValue string
+		if len(claimSplitted) > 1 {
+			claimValue = claimSplitted[1]
+		}
 
-		if !ok || claimTokenValue != claimValue {
-			result = false
+		if !cross_funcs.ArrayStringContains(getClaimValues(tokenPayloadMap, claimName, false), claimValue) {
 			app.LogWarning(fmt.Sprintf("claim %s with value %s is required", claimName, claimValue))
-			break
+			return false
 		}
 	}
 
-	return result
+	return true
 }
 
-func JwksValidationAuthentication(ctx context.Context, tokenString string, authorizationSettings *api_settings.AuthorizationSettings) bool {
-	LoadCertificates(ctx, authorizationSettings.JwksUrl)
+func claimRulesValidation(tokenPayloadMap map[string]interface{}, claimRules []*api_settings.ClaimRuleSettings) bool {
+	for _, claimRule := range claimRules {
+		values := getClaimValues(tokenPayloadMap, claimRule.Path, false)
+
+		if !claimRuleMatch(claimRule, values) {
+			app.LogWarning(fmt.Sprintf("claim %s doesn't match the rule", claimRule.Path))
+			return false
+		}
+	}
 
-	token, err := jwt.Parse(tokenString, jwksData.Keyfunc)
+	return true
+}
+
+func claimRuleMatch(claimRule *api_settings.ClaimRuleSettings, values []string) bool {
+	for _, value := range values {
+		if len(claimRule.Equals) > 0 && value == claimRule.Equals {
+			return true
+		}
+
+		if len(claimRule.Contains) > 0 && strings.Contains(value, claimRule.Contains) {
+			return true
+		}
+
+		if len(claimRule.AnyOf) > 0 && cross_funcs.ArrayStringContains(claimRule.AnyOf, value) {
+			return true
+		}
+
+		if len(claimRule.Regex) > 0 && getClaimRuleRegex(claimRule.Regex).MatchString(value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+var claimRuleRegexes sync.Map
+
+func getClaimRuleRegex(expression string) *regexp.Regexp {
+	if cached, ok := claimRuleRegexes.Load(expression); ok {
+		return cached.(*regexp.Regexp)
+	}
+
+	compiled := regexp.MustCompile(expression)
+	claimRuleRegexes.Store(expression, compiled)
+	return compiled
+}
+
+// JwksValidationAuthentication returns the claims of the token when its signature and claims are valid.
+func JwksValidationAuthentication(ctx context.Context, tokenString string, authorizationSettings *api_settings.AuthorizationSettings) (map[string]interface{}, bool) {
+	jwks := LoadCertificates(ctx, authorizationSettings)
+	if jwks == nil {
+		return nil, false
+	}
+
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(authorizationSettings.GetAllowedAlgorithms()),
+		jwt.WithoutClaimsValidation(),
+	)
+
+	token, err := parser.Parse(tokenString, jwks.Keyfunc)
 	if err != nil {
 		app.LogError2(fmt.Sprintf("Failed to parse the JWT.\nError: %s", err.Error()), err)
-		return false
+		return nil, false
+	}
+
+	if len(authorizationSettings.Kid) > 0 && token.Header["kid"] != authorizationSettings.Kid {
+		app.LogWarning(fmt.Sprintf("The token kid %v is not allowed.", token.Header["kid"]))
+		return nil, false
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, false
+	}
+
+	if err := jwtClaimsValidation(claims, authorizationSettings); err != nil {
+		app.LogWarning(fmt.Sprintf("The token is not valid. %v", err))
+		return nil, false
 	}
 
-	// Check if the token is valid.
 	if !token.Valid {
-		app.LogWarning("The token is not valid.")
+		return nil, false
 	}
 
-	return token.Valid
+	return claims, true
 }
 
-func LoadCertificates(ctx context.Context, jwksUrl string) {
+func jwtClaimsValidation(claims jwt.MapClaims, authorizationSettings *api_settings.AuthorizationSettings) error {
+	now := time.Now().Unix()
+	skew := int64(authorizationSettings.ClockSkewInSeconds)
+
+	if !claims.VerifyExpiresAt(now-skew, false) {
+		return errors.New("token is expired")
+	}
+
+	if !claims.VerifyNotBefore(now+skew, false) {
+		return errors.New("token is not valid yet")
+	}
 
-	if jwksData == nil {
-		options := keyfunc.Options{
-			Ctx: ctx,
-			RefreshErrorHandler: func(err error) {
-				app.LogError2(fmt.Sprintf("There was an error with the jwt.Keyfunc\nError: %s", err.Error()), err)
-			},
+	if !claims.VerifyIssuedAt(now+skew, false) {
+		return errors.New("token used before issued")
+	}
+
+	if len(authorizationSettings.Issuers) > 0 {
+		issuer, _ := claims["iss"].(string)
+		if !cross_funcs.ArrayStringContains(authorizationSettings.Issuers, issuer) {
+			return fmt.Errorf("issuer %v is not allowed", issuer)
 		}
-		jwks, err := keyfunc.Get(jwksUrl, options)
-		if err != nil {
-			app.LogError2(fmt.Sprintf("Failed to create JWKS from resource at the given URL.\nError: %s", err.Error()), err)
+	}
+
+	if len(authorizationSettings.Audiences) > 0 {
+		audienceValid := false
+		for _, audience := range authorizationSettings.Audiences {
+			if claims.VerifyAudience(audience, true) {
+				audienceValid = true
+				break
+			}
 		}
 
-		jwksData = jwks
+		if !audienceValid {
+			return errors.New("audience is not allowed")
+		}
+	}
+
+	for _, requiredClaim := range authorizationSettings.RequiredClaims {
+		if value, ok := claims[requiredClaim]; !ok || value == nil {
+			return fmt.Errorf("claim %v is required", requiredClaim)
+		}
+	}
+
+	return nil
+}
+
+// LoadCertificates keeps one JWKS per authorization, so several issuers can be configured at the same time.
+// Static public keys are given to the JWKS and are the only keys when there is no jwksUrl.
+// The requests waiting an issuer share its load, a failure is retried with backoff instead of on every request.
+func LoadCertificates(ctx context.Context, authorizationSettings *api_settings.AuthorizationSettings) *keyfunc.JWKS {
+	entry := getJwksEntry(authorizationSettings)
+	if jwks := entry.jwks.Load(); jwks != nil {
+		return jwks
+	}
+
+	entry.mutex.Lock()
+	defer entry.mutex.Unlock()
+
+	if jwks := entry.jwks.Load(); jwks != nil {
+		return jwks
+	}
+
+	if time.Now().Before(entry.retryAt) {
+		return nil
+	}
+
+	jwks, err := newJwks(ctx, authorizationSettings)
+	if err != nil {
+		entry.failures++
+		delay := min(jwksRetryMinDelay<<min(entry.failures-1, 6), jwksRetryMaxDelay)
+		entry.retryAt = time.Now().Add(delay)
+
+		app.LogError2(fmt.Sprintf("Failed to load the JWKS of the authorization %v, retrying in %v.\nError: %s", authorizationSettings.Id, delay, err.Error()), err)
+		return nil
+	}
+
+	entry.failures = 0
+	entry.jwks.Store(jwks)
+	return jwks
+}
+
+func getJwksEntry(authorizationSettings *api_settings.AuthorizationSettings) *jwksEntry {
+	jwksEntriesMutex.Lock()
+	defer jwksEntriesMutex.Unlock()
+
+	entry := jwksEntries[authorizationSettings]
+	if entry == nil {
+		entry = &jwksEntry{}
+		jwksEntries[authorizationSettings] = entry
+	}
+	return entry
+}
+
+func newJwks(ctx context.Context, authorizationSettings *api_settings.AuthorizationSettings) (*keyfunc.JWKS, error) {
+	givenKeys, err := loadGivenKeys(authorizationSettings.PublicKeys)
+	if err != nil {
+		return nil, fmt.Errorf("load the public keys: %w", err)
+	}
+
+	if len(authorizationSettings.JwksUrl) == 0 {
+		return keyfunc.NewGiven(givenKeys), nil
+	}
+
+	options := keyfunc.Options{
+		Ctx:               context.WithoutCancel(ctx),
+		GivenKeys:         givenKeys,
+		RefreshUnknownKID: authorizationSettings.JwksRefreshUnknownKid,
+		RefreshErrorHandler: func(err error) {
+			app.LogError2(fmt.Sprintf("There was an error with the jwt.Keyfunc\nError: %s", err.Error()), err)
+		},
+	}
+
+	if authorizationSettings.JwksRefreshIntervalInSeconds > 0 {
+		options.RefreshInterval = time.Duration(authorizationSettings.JwksRefreshIntervalInSeconds) * time.Second
+	}
+
+	if authorizationSettings.JwksRefreshUnknownKid {
+		// avoids a refresh storm from self signed tokens with random kids
+		options.RefreshRateLimit = time.Minute
+	}
+
+	jwks, err := keyfunc.Get(authorizationSettings.JwksUrl, options)
+	if err != nil {
+		return nil, fmt.Errorf("create JWKS from resource at the given URL: %w", err)
 	}
+	return jwks, nil
 }
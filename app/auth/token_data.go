@@ -14,6 +14,7 @@ type TokenData struct {
 	RefreshExpiresIn int     `json:"refresh_expires_in"`
 	TokenType        string  `json:"token_type"`
 	Scope            string  `json:"scope"`
+	RefreshToken     string  `json:"refresh_token"`
 
 	jwtPaylodData map[string]interface{}
 	CustomToken   map[string]interface{}
@@ -21,39 +22,44 @@ type TokenData struct {
 	ForceReloadSeconds int64
 	IsNotJwt           bool
 	HeaderName         string
+	ExpiresAt          time.Time `json:"-"`
 }
 
 func (token *TokenData) LoadJwtPayload() {
 	if len(token.AccessToken) > 0 && !token.IsNotJwt {
 		jwtArray := strings.Split(token.AccessToken, ".")
+		if len(jwtArray) < 2 {
+			return
+		}
 		payloadBase64 := jwtArray[1]
 		token.jwtPaylodData = auth_jwt.ConvertJwtPayloadBase64ToJwtPaylodData(payloadBase64)
 	}
 }
 
-func (token *TokenData) IsExpired(lessTimeMinutes float64, isOpaque bool) bool {
-	var exp float64
-	var ok bool
+// LoadExpiresAt should run right after the token is fetched because expires_in is relative to the response.
+func (token *TokenData) LoadExpiresAt(isOpaque bool) {
+	if token.IsNotJwt {
+		token.ExpiresAt = time.Unix(int64(token.ExpiresIn), 0)
+		return
+	}
 
-	if isOpaque || token.IsNotJwt {
-		exp = token.ExpiresIn
-	} else {
-		exp, ok = token.jwtPaylodData["exp"].(float64)
-		if !ok {
-			return true
+	if !isOpaque {
+		if exp, ok := token.jwtPaylodData["exp"].(float64); ok {
+			token.ExpiresAt = time.Unix(int64(exp), 0)
+			return
 		}
 	}
 
-	lessTimes := -time.Duration(lessTimeMinutes) * time.Minute
-	expireIn := time.Unix(int64(exp), 0).Add(lessTimes).Unix()
+	if token.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+		return
+	}
 
-	currentTime := time.Now().Unix()
+	token.ExpiresAt = time.Now()
+}
 
-	if expireIn < currentTime {
-		return true
-	} else {
-		return false
-	}
+func (token *TokenData) IsExpired(before time.Duration) bool {
+	return !time.Now().Add(before).Before(token.ExpiresAt)
 }
 
 func (token *TokenData) LoadCustomToken(forceReloadSeconds int64, accessTokenPropertyName string, tokenType string, headerName string) {
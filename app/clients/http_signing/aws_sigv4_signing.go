@@ -0,0 +1,83 @@
+package http_signing
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	client "wrench/app/clients/http"
+	"wrench/app/manifest/action_settings/http_settings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+var awsSigner = v4.NewSigner()
+var awsCredentials = make(map[string]aws.CredentialsProvider)
+var awsCredentialsMutex sync.Mutex
+
+func signAwsSigV4(ctx context.Context, request *client.HttpClientRequestData, sigV4Settings *http_settings.AwsSigV4SigningSettings) error {
+	credentialsProvider, err := getAwsCredentialsProvider(ctx, sigV4Settings)
+	if err != nil {
+		return err
+	}
+
+	awsCredential, err := credentialsProvider.Retrieve(ctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(request.Method), request.Url, bytes.NewReader(request.Body))
+	if err != nil {
+		return err
+	}
+
+	for key, value := range request.Headers {
+		req.Header.Set(key, value)
+	}
+
+	payloadHash := sha256.Sum256(request.Body)
+	err = awsSigner.SignHTTP(ctx, awsCredential, req, hex.EncodeToString(payloadHash[:]), sigV4Settings.Service, sigV4Settings.Region, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, headerName := range []string{"Authorization", "X-Amz-Date", "X-Amz-Security-Token"} {
+		if value := req.Header.Get(headerName); len(value) > 0 {
+			request.SetHeader(headerName, value)
+		}
+	}
+
+	return nil
+}
+
+func getAwsCredentialsProvider(ctx context.Context, sigV4Settings *http_settings.AwsSigV4SigningSettings) (aws.CredentialsProvider, error) {
+	awsCredentialsMutex.Lock()
+	defer awsCredentialsMutex.Unlock()
+
+	providerKey := sigV4Settings.Region + ":" + sigV4Settings.AccessKeyId
+	if provider, ok := awsCredentials[providerKey]; ok {
+		return provider, nil
+	}
+
+	var provider aws.CredentialsProvider
+	if len(sigV4Settings.AccessKeyId) > 0 {
+		provider = credentials.NewStaticCredentialsProvider(sigV4Settings.AccessKeyId, sigV4Settings.SecretAccessKey, sigV4Settings.SessionToken)
+	} else {
+		sdkConfig, err := config.LoadDefaultConfig(ctx, config.WithRegion(sigV4Settings.Region))
+		if err != nil {
+			return nil, err
+		}
+		provider = sdkConfig.Credentials
+	}
+
+	provider = aws.NewCredentialsCache(provider)
+	awsCredentials[providerKey] = provider
+	return provider, nil
+}
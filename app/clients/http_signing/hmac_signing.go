@@ -0,0 +1,76 @@
+package http_signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	client "wrench/app/clients/http"
+	"wrench/app/cross_funcs"
+	"wrench/app/manifest/action_settings/http_settings"
+)
+
+func signHmac(request *client.HttpClientRequestData, hmacSettings *http_settings.HttpRequestHmacSigningSettings) error {
+	requestUrl, err := url.Parse(request.Url)
+	if err != nil {
+		return err
+	}
+
+	bodyDigest := sha256.Sum256(request.Body)
+	bodyDigestBase64 := base64.StdEncoding.EncodeToString(bodyDigest[:])
+
+	if len(hmacSettings.BodyDigestHeader) > 0 {
+		request.SetHeader(hmacSettings.BodyDigestHeader, "SHA-256="+bodyDigestBase64)
+	}
+
+	var values []string
+	for _, component := range hmacSettings.Components {
+		switch component {
+		case http_settings.HttpRequestSigningComponentMethod:
+			values = append(values, strings.ToUpper(request.Method))
+		case http_settings.HttpRequestSigningComponentPath:
+			path := getRequestPath(requestUrl)
+			if len(requestUrl.RawQuery) > 0 {
+				path += "?" + requestUrl.RawQuery
+			}
+			values = append(values, path)
+		case http_settings.HttpRequestSigningComponentDate:
+			date, ok := getHeader(request, "Date")
+			if !ok {
+				date = time.Now().UTC().Format(http.TimeFormat)
+				request.SetHeader("Date", date)
+			}
+			values = append(values, date)
+		case http_settings.HttpRequestSigningComponentBodyDigest:
+			values = append(values, bodyDigestBase64)
+		default:
+			headerName := strings.TrimPrefix(component, http_settings.HttpRequestSigningComponentHeader)
+			value, _ := getHeader(request, headerName)
+			values = append(values, value)
+		}
+	}
+
+	hashFn := cross_funcs.GetHashFunc(hmacSettings.Algorithm)
+	if hashFn == nil {
+		return fmt.Errorf("hmac algorithm %v not supported", hmacSettings.Algorithm)
+	}
+
+	mac := hmac.New(hashFn, []byte(hmacSettings.Key))
+	mac.Write([]byte(strings.Join(values, hmacSettings.GetSeparator())))
+	signature := mac.Sum(nil)
+
+	var signatureEncoded string
+	if hmacSettings.Base64 {
+		signatureEncoded = base64.StdEncoding.EncodeToString(signature)
+	} else {
+		signatureEncoded = hex.EncodeToString(signature)
+	}
+
+	request.SetHeader(hmacSettings.GetSignatureHeader(), hmacSettings.SignaturePrefix+signatureEncoded)
+	return nil
+}
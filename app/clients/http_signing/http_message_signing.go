@@ -0,0 +1,202 @@
+package http_signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	client "wrench/app/clients/http"
+	"wrench/app/manifest/action_settings/http_settings"
+	keys_load "wrench/app/startup/keys"
+)
+
+const contentDigestComponent = "content-digest"
+
+// signHttpMessageSignature builds the signature base of RFC 9421 section 2.5 and sets Signature-Input and Signature.
+func signHttpMessageSignature(request *client.HttpClientRequestData, signatureSettings *http_settings.HttpMessageSignatureSettings) error {
+	signatureBase, signatureParams, err := getHttpMessageSignatureBase(request, signatureSettings, time.Now().Unix())
+	if err != nil {
+		return err
+	}
+
+	signature, err := signHttpMessageSignatureBase(signatureSettings, []byte(signatureBase))
+	if err != nil {
+		return err
+	}
+
+	label := signatureSettings.GetLabel()
+	request.SetHeader("Signature-Input", fmt.Sprintf("%s=%s", label, signatureParams))
+	request.SetHeader("Signature", fmt.Sprintf("%s=:%s:", label, base64.StdEncoding.EncodeToString(signature)))
+	return nil
+}
+
+// getHttpMessageSignatureBase returns the signature base and the @signature-params value created at created.
+func getHttpMessageSignatureBase(request *client.HttpClientRequestData, signatureSettings *http_settings.HttpMessageSignatureSettings, created int64) (string, string, error) {
+	requestUrl, err := url.Parse(request.Url)
+	if err != nil {
+		return "", "", err
+	}
+
+	var signatureBase strings.Builder
+	var components []string
+
+	for _, component := range signatureSettings.Components {
+		component = strings.ToLower(component)
+
+		value, err := getHttpMessageComponentValue(request, requestUrl, component)
+		if err != nil {
+			return "", "", err
+		}
+
+		components = append(components, fmt.Sprintf("%q", component))
+		signatureBase.WriteString(fmt.Sprintf("%q: %s\n", component, value))
+	}
+
+	signatureParams := fmt.Sprintf("(%s);created=%d", strings.Join(components, " "), created)
+
+	if signatureSettings.ExpiresInSeconds > 0 {
+		signatureParams += fmt.Sprintf(";expires=%d", created+int64(signatureSettings.ExpiresInSeconds))
+	}
+
+	if len(signatureSettings.Kid) > 0 {
+		signatureParams += fmt.Sprintf(";keyid=%q", signatureSettings.Kid)
+	}
+
+	signatureParams += fmt.Sprintf(";alg=%q", signatureSettings.Algorithm)
+
+	if len(signatureSettings.Tag) > 0 {
+		signatureParams += fmt.Sprintf(";tag=%q", signatureSettings.Tag)
+	}
+
+	signatureBase.WriteString(fmt.Sprintf("%q: %s", "@signature-params", signatureParams))
+
+	return signatureBase.String(), signatureParams, nil
+}
+
+func getHttpMessageComponentValue(request *client.HttpClientRequestData, requestUrl *url.URL, component string) (string, error) {
+	switch component {
+	case "@method":
+		return strings.ToUpper(request.Method), nil
+	case "@target-uri":
+		return requestUrl.String(), nil
+	case "@authority":
+		return strings.ToLower(requestUrl.Host), nil
+	case "@scheme":
+		return strings.ToLower(requestUrl.Scheme), nil
+	case "@path":
+		return getRequestPath(requestUrl), nil
+	case "@query":
+		return "?" + requestUrl.RawQuery, nil
+	case contentDigestComponent:
+		if value, ok := getHeader(request, contentDigestComponent); ok {
+			return value, nil
+		}
+
+		// RFC 9530 Content-Digest
+		digest := sha256.Sum256(request.Body)
+		value := fmt.Sprintf("sha-256=:%s:", base64.StdEncoding.EncodeToString(digest[:]))
+		request.SetHeader("Content-Digest", value)
+		return value, nil
+	}
+
+	if strings.HasPrefix(component, "@") {
+		return "", fmt.Errorf("http message signature component %v not supported", component)
+	}
+
+	value, ok := getHeader(request, component)
+	if !ok {
+		return "", fmt.Errorf("http message signature component %v isn't a request header", component)
+	}
+
+	return strings.TrimSpace(value), nil
+}
+
+// ValidHttpMessageSignatureKey checks the key type required by the algorithm, hmac-sha256 doesn't use a key of the keys.
+func ValidHttpMessageSignatureKey(algorithm http_settings.HttpMessageSignatureAlgorithm, publicKey crypto.PublicKey) error {
+	switch algorithm {
+	case http_settings.HttpMessageSignatureAlgorithmRsaPssSha512, http_settings.HttpMessageSignatureAlgorithmRsaV15Sha256:
+		if _, ok := publicKey.(*rsa.PublicKey); !ok {
+			return fmt.Errorf("http message signature %v requires a RSA key", algorithm)
+		}
+	case http_settings.HttpMessageSignatureAlgorithmEcdsaP256Sha256, http_settings.HttpMessageSignatureAlgorithmEcdsaP384Sha384:
+		curve := elliptic.P256()
+		if algorithm == http_settings.HttpMessageSignatureAlgorithmEcdsaP384Sha384 {
+			curve = elliptic.P384()
+		}
+
+		if ecdsaKey, ok := publicKey.(*ecdsa.PublicKey); !ok || ecdsaKey.Curve != curve {
+			return fmt.Errorf("http message signature %v requires an EC %v key", algorithm, curve.Params().Name)
+		}
+	case http_settings.HttpMessageSignatureAlgorithmEd25519:
+		if _, ok := publicKey.(ed25519.PublicKey); !ok {
+			return fmt.Errorf("http message signature %v requires an Ed25519 key", algorithm)
+		}
+	default:
+		return fmt.Errorf("http message signature algorithm %v doesn't use a key of the keys", algorithm)
+	}
+	return nil
+}
+
+func signHttpMessageSignatureBase(signatureSettings *http_settings.HttpMessageSignatureSettings, signatureBase []byte) ([]byte, error) {
+	if signatureSettings.Algorithm == http_settings.HttpMessageSignatureAlgorithmHmacSha256 {
+		mac := hmac.New(sha256.New, []byte(signatureSettings.Key))
+		mac.Write(signatureBase)
+		return mac.Sum(nil), nil
+	}
+
+	signer, err := keys_load.GetSigner(signatureSettings.KeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ValidHttpMessageSignatureKey(signatureSettings.Algorithm, signer.Public()); err != nil {
+		return nil, err
+	}
+
+	switch signatureSettings.Algorithm {
+	case http_settings.HttpMessageSignatureAlgorithmRsaPssSha512:
+		digest := sha512.Sum512(signatureBase)
+		return rsa.SignPSS(rand.Reader, signer.(*rsa.PrivateKey), crypto.SHA512, digest[:], &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+
+	case http_settings.HttpMessageSignatureAlgorithmRsaV15Sha256:
+		digest := sha256.Sum256(signatureBase)
+		return rsa.SignPKCS1v15(rand.Reader, signer.(*rsa.PrivateKey), crypto.SHA256, digest[:])
+
+	case http_settings.HttpMessageSignatureAlgorithmEcdsaP256Sha256:
+		digest := sha256.Sum256(signatureBase)
+		return signEcdsaFixedSize(signer.(*ecdsa.PrivateKey), digest[:])
+
+	case http_settings.HttpMessageSignatureAlgorithmEcdsaP384Sha384:
+		digest := sha512.Sum384(signatureBase)
+		return signEcdsaFixedSize(signer.(*ecdsa.PrivateKey), digest[:])
+
+	case http_settings.HttpMessageSignatureAlgorithmEd25519:
+		return ed25519.Sign(signer.(ed25519.PrivateKey), signatureBase), nil
+	}
+
+	return nil, fmt.Errorf("http message signature algorithm %v not supported", signatureSettings.Algorithm)
+}
+
+// signEcdsaFixedSize RFC 9421 section 3.3.4 and 3.3.5, the signature is r and s concatenated, not ASN.1.
+func signEcdsaFixedSize(privateKey *ecdsa.PrivateKey, digest []byte) ([]byte, error) {
+	r, s, err := ecdsa.Sign(rand.Reader, privateKey, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	size := (privateKey.Curve.Params().BitSize + 7) / 8
+	signature := make([]byte, 2*size)
+	r.FillBytes(signature[:size])
+	s.FillBytes(signature[size:])
+	return signature, nil
+}
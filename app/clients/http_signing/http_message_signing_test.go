@@ -0,0 +1,311 @@
+package http_signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	client "wrench/app/clients/http"
+	"wrench/app/manifest/action_settings/http_settings"
+	keys_load "wrench/app/startup/keys"
+)
+
+// RFC 9421 Appendix B.2, the test request and created of the examples
+const rfc9421Created = 1618884473
+
+func newRfc9421TestRequest() *client.HttpClientRequestData {
+	return &client.HttpClientRequestData{
+		Url:    "https://example.com/foo?param=Value&Pet=dog",
+		Method: "POST",
+		Body:   []byte(`{"hello": "world"}`),
+		Headers: map[string]string{
+			"Host":           "example.com",
+			"Date":           "Tue, 20 Apr 2021 02:07:55 GMT",
+			"Content-Type":   "application/json",
+			"Content-Digest": "sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:",
+			"Content-Length": "18",
+		},
+	}
+}
+
+// RFC 9421 Appendix B.2.3, the alg parameter is always added to the signature params
+func TestHttpMessageSignatureBaseRfc9421AppendixB23(t *testing.T) {
+	settings := &http_settings.HttpMessageSignatureSettings{
+		Algorithm:  http_settings.HttpMessageSignatureAlgorithmRsaPssSha512,
+		Kid:        "test-key-rsa-pss",
+		Components: []string{"date", "@method", "@path", "@query", "@authority", "content-type", "content-digest", "content-length"},
+	}
+
+	signatureBase, signatureParams, err := getHttpMessageSignatureBase(newRfc9421TestRequest(), settings, rfc9421Created)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedParams := `("date" "@method" "@path" "@query" "@authority" "content-type" "content-digest" "content-length");created=1618884473;keyid="test-key-rsa-pss";alg="rsa-pss-sha512"`
+	expected := strings.Join([]string{
+		`"date": Tue, 20 Apr 2021 02:07:55 GMT`,
+		`"@method": POST`,
+		`"@path": /foo`,
+		`"@query": ?param=Value&Pet=dog`,
+		`"@authority": example.com`,
+		`"content-type": application/json`,
+		`"content-digest": sha-512=:WZDPaVn/7XgHaAy8pmojAkGWoRx2UFChF41A2svX+TaPm+AbwAgBWnrIiYllu7BNNyealdVLvRwEmTHWXvJwew==:`,
+		`"content-length": 18`,
+		`"@signature-params": ` + expectedParams,
+	}, "\n")
+
+	if signatureBase != expected {
+		t.Errorf("expected signature base\n%s\ngot\n%s", expected, signatureBase)
+	}
+	if signatureParams != expectedParams {
+		t.Errorf("expected %s, got %s", expectedParams, signatureParams)
+	}
+}
+
+func TestHttpMessageSignatureComponents(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		component string
+		expected  string
+	}{
+		{"query", "https://example.com/foo?param=Value&Pet=dog", "@query", "?param=Value&Pet=dog"},
+		{"empty query", "https://example.com/foo", "@query", "?"},
+		{"target uri", "https://example.com/foo?param=Value&Pet=dog", "@target-uri", "https://example.com/foo?param=Value&Pet=dog"},
+		{"authority", "https://Example.com:8443/foo", "@authority", "example.com:8443"},
+		{"scheme", "https://example.com/foo", "@scheme", "https"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := newRfc9421TestRequest()
+			request.Url = test.url
+
+			settings := &http_settings.HttpMessageSignatureSettings{Components: []string{test.component}}
+			signatureBase, _, err := getHttpMessageSignatureBase(request, settings, rfc9421Created)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if firstLine := strings.Split(signatureBase, "\n")[0]; firstLine != `"`+test.component+`": `+test.expected {
+				t.Errorf("expected %s, got %s", test.expected, firstLine)
+			}
+		})
+	}
+}
+
+// RFC 9530 Appendix B.1, the content-digest is created with sha-256 when the request doesn't have it
+func TestHttpMessageSignatureCreatesContentDigest(t *testing.T) {
+	request := newRfc9421TestRequest()
+	delete(request.Headers, "Content-Digest")
+
+	settings := &http_settings.HttpMessageSignatureSettings{Components: []string{"content-digest"}}
+	signatureBase, _, err := getHttpMessageSignatureBase(request, settings, rfc9421Created)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "sha-256=:X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=:"
+	if !strings.HasPrefix(signatureBase, `"content-digest": `+expected+"\n") {
+		t.Errorf("expected content-digest %s, got %s", expected, signatureBase)
+	}
+	if value, _ := getHeader(request, "content-digest"); value != expected {
+		t.Errorf("expected the Content-Digest header %s, got %s", expected, value)
+	}
+}
+
+// RFC 9421 Appendix B.2.5, hmac-sha256 with test-shared-secret
+func TestSignHttpMessageSignatureBaseRfc9421AppendixB25(t *testing.T) {
+	secret, err := base64.StdEncoding.DecodeString("uzvJfB4u3N0Jy4T7NZ75MDVcr8zSTInedJtkgcu46YW4XByzNJjxBdtjUkdJPBtbmHhIDi6pcl8jsasjlTMtDQ==")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signatureBase := strings.Join([]string{
+		`"date": Tue, 20 Apr 2021 02:07:55 GMT`,
+		`"@authority": example.com`,
+		`"content-type": application/json`,
+		`"@signature-params": ("date" "@authority" "content-type");created=1618884473;keyid="test-shared-secret"`,
+	}, "\n")
+
+	settings := &http_settings.HttpMessageSignatureSettings{
+		Algorithm: http_settings.HttpMessageSignatureAlgorithmHmacSha256,
+		Key:       string(secret),
+	}
+
+	signature, err := signHttpMessageSignatureBase(settings, []byte(signatureBase))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if encoded := base64.StdEncoding.EncodeToString(signature); encoded != "pxcQw6G3AjtMBQjwo8XzkZf/bws5LelbaMk5rGIGtE8=" {
+		t.Errorf("expected pxcQw6G3AjtMBQjwo8XzkZf/bws5LelbaMk5rGIGtE8=, got %v", encoded)
+	}
+}
+
+// RFC 9421 Appendix B.2.6, ed25519 with test-key-ed25519
+func TestSignHttpMessageSignatureBaseRfc9421AppendixB26(t *testing.T) {
+	seed, err := base64.RawURLEncoding.DecodeString("n4Ni-HpISpVObnQMW0wOhCKROaIKqKtW_2ZYb2p9KcU")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privateKey := ed25519.NewKeyFromSeed(seed)
+	if publicX := base64.RawURLEncoding.EncodeToString(privateKey.Public().(ed25519.PublicKey)); publicX != "JrQLj5P_89iXES9-vFgrIy29clF9CC_oPPsw3c5D0bs" {
+		t.Fatalf("public key %v doesn't match the example", publicX)
+	}
+	loadTestPrivateKey(t, "test-key-ed25519", privateKey)
+
+	signatureBase := strings.Join([]string{
+		`"date": Tue, 20 Apr 2021 02:07:55 GMT`,
+		`"@method": POST`,
+		`"@path": /foo`,
+		`"@authority": example.com`,
+		`"content-type": application/json`,
+		`"content-length": 18`,
+		`"@signature-params": ("date" "@method" "@path" "@authority" "content-type" "content-length");created=1618884473;keyid="test-key-ed25519"`,
+	}, "\n")
+
+	settings := &http_settings.HttpMessageSignatureSettings{
+		Algorithm: http_settings.HttpMessageSignatureAlgorithmEd25519,
+		KeyId:     "test-key-ed25519",
+	}
+
+	signature, err := signHttpMessageSignatureBase(settings, []byte(signatureBase))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "wqcAqbmYJ2ji2glfAMaRy4gruYYnx2nEFN2HN6jrnDnQCK1u02Gb04v9EDgwUPiu4A0w6vuQv5lIp5WPpBKRCw=="
+	if encoded := base64.StdEncoding.EncodeToString(signature); encoded != expected {
+		t.Errorf("expected %v, got %v", expected, encoded)
+	}
+}
+
+func TestSignHttpMessageSignatureBaseVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loadTestPrivateKey(t, "test-key-rsa", rsaKey)
+	loadTestPrivateKey(t, "test-key-p256", p256Key)
+	loadTestPrivateKey(t, "test-key-p384", p384Key)
+
+	signatureBase := []byte(`"@method": POST` + "\n" + `"@signature-params": ("@method");created=1618884473`)
+
+	tests := []struct {
+		name      string
+		algorithm http_settings.HttpMessageSignatureAlgorithm
+		keyId     string
+		verify    func(signature []byte) bool
+	}{
+		{"rsa-pss-sha512", http_settings.HttpMessageSignatureAlgorithmRsaPssSha512, "test-key-rsa", func(signature []byte) bool {
+			digest := sha512.Sum512(signatureBase)
+			return rsa.VerifyPSS(&rsaKey.PublicKey, crypto.SHA512, digest[:], signature, nil) == nil
+		}},
+		{"rsa-v1_5-sha256", http_settings.HttpMessageSignatureAlgorithmRsaV15Sha256, "test-key-rsa", func(signature []byte) bool {
+			digest := sha256.Sum256(signatureBase)
+			return rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA256, digest[:], signature) == nil
+		}},
+		{"ecdsa-p256-sha256", http_settings.HttpMessageSignatureAlgorithmEcdsaP256Sha256, "test-key-p256", func(signature []byte) bool {
+			digest := sha256.Sum256(signatureBase)
+			return verifyEcdsaFixedSize(&p256Key.PublicKey, digest[:], signature, 32)
+		}},
+		{"ecdsa-p384-sha384", http_settings.HttpMessageSignatureAlgorithmEcdsaP384Sha384, "test-key-p384", func(signature []byte) bool {
+			digest := sha512.Sum384(signatureBase)
+			return verifyEcdsaFixedSize(&p384Key.PublicKey, digest[:], signature, 48)
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			settings := &http_settings.HttpMessageSignatureSettings{Algorithm: test.algorithm, KeyId: test.keyId}
+
+			signature, err := signHttpMessageSignatureBase(settings, signatureBase)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !test.verify(signature) {
+				t.Error("signature doesn't verify")
+			}
+		})
+	}
+}
+
+func TestValidHttpMessageSignatureKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublicKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		algorithm http_settings.HttpMessageSignatureAlgorithm
+		publicKey crypto.PublicKey
+		valid     bool
+	}{
+		{"rsa key with rsa-pss-sha512", http_settings.HttpMessageSignatureAlgorithmRsaPssSha512, rsaKey.Public(), true},
+		{"ec key with rsa-pss-sha512", http_settings.HttpMessageSignatureAlgorithmRsaPssSha512, p256Key.Public(), false},
+		{"ed25519 key with rsa-v1_5-sha256", http_settings.HttpMessageSignatureAlgorithmRsaV15Sha256, edPublicKey, false},
+		{"p256 key with ecdsa-p256-sha256", http_settings.HttpMessageSignatureAlgorithmEcdsaP256Sha256, p256Key.Public(), true},
+		{"p256 key with ecdsa-p384-sha384", http_settings.HttpMessageSignatureAlgorithmEcdsaP384Sha384, p256Key.Public(), false},
+		{"ed25519 key with ed25519", http_settings.HttpMessageSignatureAlgorithmEd25519, edPublicKey, true},
+		{"rsa key with ed25519", http_settings.HttpMessageSignatureAlgorithmEd25519, rsaKey.Public(), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := ValidHttpMessageSignatureKey(test.algorithm, test.publicKey); (err == nil) != test.valid {
+				t.Errorf("expected valid %v, got %v", test.valid, err)
+			}
+		})
+	}
+}
+
+func loadTestPrivateKey(t *testing.T, keyId string, privateKey crypto.Signer) {
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := keys_load.LoadPemPrivateKey(keyId, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func verifyEcdsaFixedSize(publicKey *ecdsa.PublicKey, digest []byte, signature []byte, size int) bool {
+	if len(signature) != 2*size {
+		return false
+	}
+
+	r := new(big.Int).SetBytes(signature[:size])
+	s := new(big.Int).SetBytes(signature[size:])
+	return ecdsa.Verify(publicKey, digest, r, s)
+}
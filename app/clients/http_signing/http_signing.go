@@ -0,0 +1,45 @@
+package http_signing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	client "wrench/app/clients/http"
+	"wrench/app/manifest/action_settings/http_settings"
+)
+
+// SignRequest adds the signature headers of the profile, it should run after every other header is set.
+func SignRequest(ctx context.Context, request *client.HttpClientRequestData, signing *http_settings.HttpRequestSigningSettings) error {
+	if signing == nil {
+		return nil
+	}
+
+	switch signing.Type {
+	case http_settings.HttpRequestSigningTypeHmac:
+		return signHmac(request, signing.Hmac)
+	case http_settings.HttpRequestSigningTypeHttpMessageSignature:
+		return signHttpMessageSignature(request, signing.HttpMessageSignature)
+	case http_settings.HttpRequestSigningTypeAwsSigV4:
+		return signAwsSigV4(ctx, request, signing.AwsSigV4)
+	}
+
+	return fmt.Errorf("signing type %v not supported", signing.Type)
+}
+
+func getHeader(request *client.HttpClientRequestData, name string) (string, bool) {
+	for key, value := range request.Headers {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func getRequestPath(requestUrl *url.URL) string {
+	path := requestUrl.EscapedPath()
+	if len(path) == 0 {
+		path = "/"
+	}
+	return path
+}
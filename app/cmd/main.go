@@ -39,7 +39,19 @@ func main() {
 		app.LogError2(fmt.Sprintf("Error loading YAML: %v", err), err)
 	}
 
-	byteArray = startup.EnvInterpolation(byteArray)
+	byteArray, err = startup.EnvInterpolation(byteArray)
+	if err != nil {
+		app.LogError2(fmt.Sprintf("Error resolving envs: %v", err), err)
+		os.Exit(1)
+	}
+
+	interpolatedByteArray := byteArray
+	byteArray, err = startup.LoadSecretRefs(ctx, byteArray)
+	if err != nil {
+		app.LogError2(fmt.Sprintf("Error loading secrets: %v", err), err)
+		os.Exit(1)
+	}
+
 	applicationSetting, err := application_settings.ParseMapToApplicationSetting(byteArray)
 
 	if err != nil {
@@ -68,10 +80,35 @@ func main() {
 	keys_load.LoadKeys()
 
 	go token_credentials.LoadTokenCredentialAuthentication()
+	go startup.RefreshSecrets(ctx, interpolatedByteArray, applicationSetting)
 	hanlder := startup.LoadApplicationSettings(ctx, applicationSetting)
 	port := getPort()
 	app.LogInfo(fmt.Sprintf("Server listen in port %s", port))
-	http.ListenAndServe(port, handlers.CaseInsensitiveMux(hanlder))
+	listenAndServe(port, handlers.CaseInsensitiveMux(hanlder), applicationSetting)
+}
+
+func listenAndServe(port string, handler http.Handler, applicationSetting *application_settings.ApplicationSettings) {
+	if applicationSetting.Api == nil || applicationSetting.Api.Tls == nil {
+		if err := http.ListenAndServe(port, handler); err != nil {
+			app.LogError2(fmt.Sprintf("Error server: %v", err), err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	tlsSettings := applicationSetting.Api.Tls
+	tlsConfig, err := startup.LoadTlsConfig(tlsSettings)
+	if err != nil {
+		app.LogError2(fmt.Sprintf("Error loading TLS: %v", err), err)
+		os.Exit(1)
+	}
+
+	server := &http.Server{Addr: port, Handler: handler, TLSConfig: tlsConfig}
+	err = server.ListenAndServeTLS(tlsSettings.CertFile, tlsSettings.KeyFile)
+	if err != nil {
+		app.LogError2(fmt.Sprintf("Error TLS server: %v", err), err)
+		os.Exit(1)
+	}
 }
 
 func loadBashFiles() {
@@ -3,6 +3,7 @@ package contexts
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 	"wrench/app/manifest/action_settings"
 )
@@ -13,6 +14,24 @@ type BodyContext struct {
 	HttpStatusCode       int
 	ContentType          string
 	Headers              map[string]string
+	ResponseHeaders      map[string]http.Header
+}
+
+// SetResponseHeaders keeps all the headers answered to the action, they are read by
+// {{bodyContext.responseHeaders.actionId.Header-Name}} and aren't sent to the client.
+func (bodyContext *BodyContext) SetResponseHeaders(actionId string, headers http.Header) {
+	if bodyContext.ResponseHeaders == nil {
+		bodyContext.ResponseHeaders = make(map[string]http.Header)
+	}
+
+	bodyContext.ResponseHeaders[actionId] = headers
+}
+
+func (bodyContext *BodyContext) GetResponseHeader(actionId string, name string) string {
+	if bodyContext.ResponseHeaders == nil {
+		return ""
+	}
+	return bodyContext.ResponseHeaders[actionId].Get(name)
 }
 
 func (bodyContext *BodyContext) SetBodyPreserved(id string, body []byte) {
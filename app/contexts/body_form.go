@@ -0,0 +1,243 @@
+package contexts
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+	"wrench/app/json_map"
+	"wrench/app/manifest/api_settings"
+)
+
+const ContentTypeFormUrlEncoded = "application/x-www-form-urlencoded"
+const ContentTypeMultipartFormData = "multipart/form-data"
+
+const formFilePropertyFileName = "fileName"
+const formFilePropertyContentType = "contentType"
+const formFilePropertySize = "size"
+const formFilePropertyContent = "content"
+const formFilePropertyEncoding = "encoding"
+
+func IsFormContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == ContentTypeFormUrlEncoded || mediaType == ContentTypeMultipartFormData
+}
+
+func ParseFormBodyToMap(body []byte, contentType string, formSettings *api_settings.FormSettings) (map[string]interface{}, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	if mediaType == ContentTypeFormUrlEncoded {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, err
+		}
+		return formValuesToMap(make(map[string]interface{}), values), nil
+	}
+
+	if mediaType == ContentTypeMultipartFormData {
+		boundary := params["boundary"]
+		if len(boundary) == 0 {
+			return nil, fmt.Errorf("multipart boundary not informed")
+		}
+
+		reader := multipart.NewReader(bytes.NewReader(body), boundary)
+		form, err := reader.ReadForm(formSettings.GetMaxMemory())
+		if err != nil {
+			return nil, err
+		}
+		defer form.RemoveAll()
+
+		jsonMap := formValuesToMap(make(map[string]interface{}), form.Value)
+		fileEncoding := formSettings.GetFileEncoding()
+
+		for name, fileHeaders := range form.File {
+			var files []interface{}
+			for _, fileHeader := range fileHeaders {
+				file, err := formFileToMap(fileHeader, fileEncoding)
+				if err != nil {
+					return nil, err
+				}
+				files = append(files, file)
+			}
+
+			if len(files) == 1 {
+				jsonMap = json_map.CreateProperty(jsonMap, name, files[0])
+			} else {
+				jsonMap = json_map.CreateProperty(jsonMap, name, files)
+			}
+		}
+
+		return jsonMap, nil
+	}
+
+	return nil, fmt.Errorf("content type %v is not a form", mediaType)
+}
+
+func formValuesToMap(jsonMap map[string]interface{}, values map[string][]string) map[string]interface{} {
+	for name, value := range values {
+		if len(value) == 1 {
+			jsonMap = json_map.CreateProperty(jsonMap, name, value[0])
+		} else {
+			valueArray := make([]interface{}, len(value))
+			for i, item := range value {
+				valueArray[i] = item
+			}
+			jsonMap = json_map.CreateProperty(jsonMap, name, valueArray)
+		}
+	}
+
+	return jsonMap
+}
+
+func formFileToMap(fileHeader *multipart.FileHeader, fileEncoding api_settings.FormFileEncoding) (map[string]interface{}, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	// binary content isn't valid in a JSON string, passthrough falls back to base64
+	if fileEncoding == api_settings.FormFileEncodingPassthrough && !utf8.Valid(content) {
+		fileEncoding = api_settings.FormFileEncodingBase64
+	}
+
+	fileMap := make(map[string]interface{})
+	fileMap[formFilePropertyFileName] = fileHeader.Filename
+	fileMap[formFilePropertyContentType] = fileHeader.Header.Get("Content-Type")
+	fileMap[formFilePropertySize] = fileHeader.Size
+	fileMap[formFilePropertyEncoding] = string(fileEncoding)
+
+	if fileEncoding == api_settings.FormFileEncodingPassthrough {
+		fileMap[formFilePropertyContent] = string(content)
+	} else {
+		fileMap[formFilePropertyContent] = base64.StdEncoding.EncodeToString(content)
+	}
+
+	return fileMap, nil
+}
+
+type FormFile struct {
+	FieldName   string
+	FileName    string
+	ContentType string
+	Content     []byte
+}
+
+// NewFormFile accepts either a file object produced by ParseFormBodyToMap or a raw content value.
+func NewFormFile(fieldName string, value interface{}, fileName string, contentType string, isBase64 bool) (*FormFile, error) {
+	formFile := &FormFile{FieldName: fieldName, FileName: fileName, ContentType: contentType}
+	var content string
+
+	if fileMap, ok := value.(map[string]interface{}); ok {
+		if len(formFile.FileName) == 0 {
+			formFile.FileName, _ = fileMap[formFilePropertyFileName].(string)
+		}
+		if len(formFile.ContentType) == 0 {
+			if mapContentType, ok := fileMap[formFilePropertyContentType].(string); ok {
+				formFile.ContentType = mapContentType
+			}
+		}
+		content, _ = fileMap[formFilePropertyContent].(string)
+		isBase64 = fileMap[formFilePropertyEncoding] != string(api_settings.FormFileEncodingPassthrough)
+	} else if value != nil {
+		content = fmt.Sprint(value)
+	}
+
+	if isBase64 {
+		decoded, err := base64.StdEncoding.DecodeString(content)
+		if err != nil {
+			return nil, fmt.Errorf("form file %v content is not base64: %w", fieldName, err)
+		}
+		formFile.Content = decoded
+	} else {
+		formFile.Content = []byte(content)
+	}
+
+	if len(formFile.FileName) == 0 {
+		formFile.FileName = fieldName
+	}
+
+	if len(formFile.ContentType) == 0 {
+		formFile.ContentType = "application/octet-stream"
+	}
+
+	return formFile, nil
+}
+
+func BuildFormUrlEncodedBody(fields map[string]interface{}) []byte {
+	data := url.Values{}
+
+	for key, value := range fields {
+		if values, ok := value.([]interface{}); ok {
+			for _, item := range values {
+				data.Add(key, fmt.Sprint(item))
+			}
+		} else if value != nil {
+			data.Set(key, fmt.Sprint(value))
+		}
+	}
+
+	return []byte(data.Encode())
+}
+
+func BuildMultipartBody(fields map[string]interface{}, files []*FormFile) ([]byte, string, error) {
+	var buffer bytes.Buffer
+	writer := multipart.NewWriter(&buffer)
+
+	for key, value := range fields {
+		if values, ok := value.([]interface{}); ok {
+			for _, item := range values {
+				if err := writer.WriteField(key, fmt.Sprint(item)); err != nil {
+					return nil, "", err
+				}
+			}
+		} else if value != nil {
+			if err := writer.WriteField(key, fmt.Sprint(value)); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	for _, file := range files {
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeQuotes(file.FieldName), escapeQuotes(file.FileName)))
+		header.Set("Content-Type", file.ContentType)
+
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if _, err := part.Write(file.Content); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return buffer.Bytes(), writer.FormDataContentType(), nil
+}
+
+func escapeQuotes(value string) string {
+	return strings.NewReplacer("\\", "\\\\", `"`, "\\\"").Replace(value)
+}
@@ -0,0 +1,61 @@
+package contexts
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// GetClientCertificate returns the leaf certificate verified during the TLS handshake.
+func GetClientCertificate(request *http.Request) *x509.Certificate {
+	if request == nil || request.TLS == nil {
+		return nil
+	}
+
+	if len(request.TLS.VerifiedChains) > 0 && len(request.TLS.VerifiedChains[0]) > 0 {
+		return request.TLS.VerifiedChains[0][0]
+	}
+
+	return nil
+}
+
+func GetClientCertificateSans(cert *x509.Certificate) []string {
+	var sans []string
+	sans = append(sans, cert.DNSNames...)
+	sans = append(sans, cert.EmailAddresses...)
+
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+
+	return sans
+}
+
+func GetClientCertificateFingerprint(cert *x509.Certificate) string {
+	fingerprint := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(fingerprint[:])
+}
+
+func GetRequestClientCert(wrenchContext *WrenchContext, propertyName string) string {
+	cert := GetClientCertificate(wrenchContext.Request)
+	if cert == nil {
+		return ""
+	}
+
+	switch propertyName {
+	case "subject":
+		return cert.Subject.String()
+	case "san":
+		return strings.Join(GetClientCertificateSans(cert), ",")
+	case "fingerprint":
+		return GetClientCertificateFingerprint(cert)
+	}
+
+	return ""
+}
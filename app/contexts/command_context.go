@@ -3,6 +3,7 @@ package contexts
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"strconv"
 	"strings"
 	"time"
@@ -11,6 +12,8 @@ import (
 	"wrench/app/json_map"
 	settings "wrench/app/manifest/action_settings"
 	"wrench/app/manifest/action_settings/func_settings"
+	"wrench/app/manifest/api_settings"
+	"wrench/app/manifest/application_settings"
 	"wrench/app/manifest/contract_settings/maps"
 
 	"github.com/google/uuid"
@@ -21,8 +24,12 @@ const prefixWrenchContextRequestUri = "wrenchContext.request.uri"
 const prefixWrenchContextRequestUriParams = "wrenchContext.request.uri.params."
 const prefixWrenchContextRequestTokenClaims = "wrenchContext.request.token.claims."
 const prefixWrenchContextRequestHeaders = "wrenchContext.request.headers."
+const wrenchContextRequestClientIp = "wrenchContext.request.clientIp"
+const prefixWrenchContextRequestConsumer = "wrenchContext.request.consumer."
+const prefixWrenchContextRequestClientCert = "wrenchContext.request.clientCert."
 const prefixBodyContext = "bodyContext."
 const prefixBodyContextPreserved = "bodyContext.actions."
+const prefixBodyContextResponseHeaders = "bodyContext.responseHeaders."
 const prefixFunc = "func."
 
 func IsCalculatedValue(value string) bool {
@@ -63,6 +70,10 @@ func GetRequestUriParams(wrenchContext *WrenchContext, parameterName string) str
 }
 
 func GetTokenClaims(wrenchContext *WrenchContext, claimName string) string {
+	if wrenchContext.TokenClaims != nil {
+		return getTokenClaimValue(wrenchContext.TokenClaims, claimName)
+	}
+
 	tokenString := wrenchContext.Request.Header.Get("Authorization")
 
 	if len(tokenString) == 0 {
@@ -72,12 +83,105 @@ func GetTokenClaims(wrenchContext *WrenchContext, claimName string) string {
 	tokenString = strings.Replace(tokenString, "Bearer ", "", 1)
 
 	tokenSplitted := strings.Split(tokenString, ".")
+	if len(tokenSplitted) < 2 {
+		return ""
+	}
 	tokenPayload := tokenSplitted[1]
 
 	tokenPayloadMap := auth_jwt.ConvertJwtPayloadBase64ToJwtPaylodData(tokenPayload)
-	claimTokenValue, _ := tokenPayloadMap[claimName].(string)
 
-	return claimTokenValue
+	return getTokenClaimValue(tokenPayloadMap, claimName)
+}
+
+// getTokenClaimValue accepts json_map paths (realm_access.roles), arrays are joined with ",".
+func getTokenClaimValue(tokenPayloadMap map[string]interface{}, claimName string) string {
+	if tokenPayloadMap == nil {
+		return ""
+	}
+
+	value, _ := json_map.GetValue(tokenPayloadMap, claimName, false)
+
+	switch claimValue := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return claimValue
+	case []interface{}:
+		values := make([]string, 0, len(claimValue))
+		for _, item := range claimValue {
+			values = append(values, fmt.Sprint(item))
+		}
+		return strings.Join(values, ",")
+	case map[string]interface{}:
+		valueBytes, _ := json.Marshal(claimValue)
+		return string(valueBytes)
+	default:
+		return fmt.Sprint(claimValue)
+	}
+}
+
+// GetRequestClientIp is the remote address, api.clientIp.forwardedHeader is used only when the request comes
+// from a trusted proxy, so callers can't choose their ip (e.g. a new rate limit bucket per request).
+func GetRequestClientIp(wrenchContext *WrenchContext) string {
+	clientIp, _, err := net.SplitHostPort(wrenchContext.Request.RemoteAddr)
+	if err != nil {
+		clientIp = wrenchContext.Request.RemoteAddr
+	}
+
+	var clientIpSettings *api_settings.ClientIpSettings
+	if appSettings := application_settings.ApplicationSettingsStatic; appSettings != nil && appSettings.Api != nil {
+		clientIpSettings = appSettings.Api.ClientIp
+	}
+
+	if !clientIpSettings.IsTrustedProxy(clientIp) {
+		return clientIp
+	}
+
+	if !clientIpSettings.IsForwardedFor() {
+		forwardedIp := strings.TrimSpace(wrenchContext.Request.Header.Get(clientIpSettings.GetForwardedHeader()))
+		if net.ParseIP(forwardedIp) == nil {
+			return clientIp
+		}
+		return forwardedIp
+	}
+
+	// X-Forwarded-For is walked from the right, the first hop that isn't a trusted proxy is the client
+	var hops []string
+	for _, forwardedFor := range wrenchContext.Request.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(forwardedFor, ",")...)
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+
+		clientIp = hop
+		if !clientIpSettings.IsTrustedProxy(hop) {
+			break
+		}
+	}
+
+	return clientIp
+}
+
+func GetRequestConsumer(wrenchContext *WrenchContext, propertyName string) string {
+	consumer := wrenchContext.Consumer
+	if consumer == nil {
+		return ""
+	}
+
+	switch propertyName {
+	case "id":
+		return consumer.Id
+	case "roles":
+		return strings.Join(consumer.Roles, ",")
+	case "rateLimitTier":
+		return consumer.RateLimitTier
+	}
+
+	return ""
 }
 
 func GetValueWrenchContext(command string, wrenchContext *WrenchContext) string {
@@ -101,6 +205,20 @@ func GetValueWrenchContext(command string, wrenchContext *WrenchContext) string
 		return GetTokenClaims(wrenchContext, parameterName)
 	}
 
+	if strings.HasPrefix(command, prefixWrenchContextRequestConsumer) {
+		propertyName := strings.ReplaceAll(command, prefixWrenchContextRequestConsumer, "")
+		return GetRequestConsumer(wrenchContext, propertyName)
+	}
+
+	if strings.HasPrefix(command, prefixWrenchContextRequestClientCert) {
+		propertyName := strings.ReplaceAll(command, prefixWrenchContextRequestClientCert, "")
+		return GetRequestClientCert(wrenchContext, propertyName)
+	}
+
+	if command == wrenchContextRequestClientIp {
+		return GetRequestClientIp(wrenchContext)
+	}
+
 	if strings.HasPrefix(command, prefixWrenchContextRequestUri) {
 		return wrenchContext.Request.RequestURI
 	}
@@ -140,7 +258,10 @@ func GetValueBodyContext(command string, bodyContext *BodyContext) interface{} {
 		command = ReplaceCalculatedValue(command)
 	}
 
-	if strings.HasPrefix(command, prefixBodyContextPreserved) {
+	if strings.HasPrefix(command, prefixBodyContextResponseHeaders) {
+		actionId, headerName, _ := strings.Cut(strings.TrimPrefix(command, prefixBodyContextResponseHeaders), ".")
+		return bodyContext.GetResponseHeader(actionId, headerName)
+	} else if strings.HasPrefix(command, prefixBodyContextPreserved) {
 		bodyPreservedMap := strings.ReplaceAll(command, prefixBodyContextPreserved, "")
 		bodyPreservedMapSplitted := strings.Split(bodyPreservedMap, ".")
 		actionId := bodyPreservedMapSplitted[0]
@@ -0,0 +1,134 @@
+package contexts
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"wrench/app/cross_funcs"
+	"wrench/app/json_map"
+	"wrench/app/manifest/contract_settings/maps"
+	"wrench/app/manifest/types"
+	keys_load "wrench/app/startup/keys"
+)
+
+// EncryptValue binds additionalData to the A256GCM ciphertext, JWE authenticates only its protected header.
+func EncryptValue(keyId string, alg types.EncryptionAlg, kid string, contentType string, plaintext []byte, additionalData []byte) (string, error) {
+	if alg == types.EncryptionAlgA256GCM {
+		secretKey, err := keys_load.GetSecretKey(keyId)
+		if err != nil {
+			return "", err
+		}
+
+		ciphertext, err := cross_funcs.EncryptAesGcm(secretKey, plaintext, additionalData)
+		if err != nil {
+			return "", err
+		}
+		return base64.StdEncoding.EncodeToString(ciphertext), nil
+	}
+
+	publicKey, err := keys_load.GetPublicKey(keyId)
+	if err != nil {
+		return "", err
+	}
+	return cross_funcs.EncryptJwe(string(alg), publicKey, kid, contentType, plaintext)
+}
+
+func DecryptValue(keyId string, alg types.EncryptionAlg, value string, additionalData []byte) ([]byte, error) {
+	if alg == types.EncryptionAlgA256GCM {
+		secretKey, err := keys_load.GetSecretKey(keyId)
+		if err != nil {
+			return nil, err
+		}
+
+		ciphertext, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, err
+		}
+		return cross_funcs.DecryptAesGcm(secretKey, ciphertext, additionalData)
+	}
+
+	privateKey, err := keys_load.GetSigner(keyId)
+	if err != nil {
+		return nil, err
+	}
+	return cross_funcs.DecryptJwe(string(alg), privateKey, value)
+}
+
+func EncryptFields(jsonMap map[string]interface{}, crypto *maps.CryptoSettings) (map[string]interface{}, error) {
+	for _, field := range crypto.Fields {
+		value, _ := json_map.GetValue(jsonMap, field, false)
+		if value == nil {
+			continue
+		}
+
+		plaintext, err := json.Marshal(value)
+		if err != nil {
+			return jsonMap, err
+		}
+
+		additionalData, err := getFieldAdditionalData(jsonMap, crypto, field)
+		if err != nil {
+			return jsonMap, err
+		}
+
+		encrypted, err := EncryptValue(crypto.KeyId, crypto.Algorithm, crypto.Kid, "", plaintext, additionalData)
+		if err != nil {
+			return jsonMap, fmt.Errorf("encrypt field %v: %w", field, err)
+		}
+
+		_, jsonMap = json_map.GetValue(jsonMap, field, true)
+		jsonMap = json_map.CreateProperty(jsonMap, field, encrypted)
+	}
+
+	return jsonMap, nil
+}
+
+// DecryptFields restores the type encrypted as JSON, a plaintext that isn't JSON (e.g. encrypted
+// by a partner) is returned as string.
+func DecryptFields(jsonMap map[string]interface{}, crypto *maps.CryptoSettings) (map[string]interface{}, error) {
+	for _, field := range crypto.Fields {
+		value, _ := json_map.GetValue(jsonMap, field, false)
+		encrypted, ok := value.(string)
+		if !ok || len(encrypted) == 0 {
+			continue
+		}
+
+		additionalData, err := getFieldAdditionalData(jsonMap, crypto, field)
+		if err != nil {
+			return jsonMap, err
+		}
+
+		plaintext, err := DecryptValue(crypto.KeyId, crypto.Algorithm, encrypted, additionalData)
+		if err != nil {
+			return jsonMap, fmt.Errorf("decrypt field %v: %w", field, err)
+		}
+
+		var decrypted interface{}
+		if json.Unmarshal(plaintext, &decrypted) != nil {
+			decrypted = string(plaintext)
+		}
+
+		_, jsonMap = json_map.GetValue(jsonMap, field, true)
+		jsonMap = json_map.CreateProperty(jsonMap, field, decrypted)
+	}
+
+	return jsonMap, nil
+}
+
+// getFieldAdditionalData is the JSON array [field, contextField values...], only A256GCM uses it.
+func getFieldAdditionalData(jsonMap map[string]interface{}, crypto *maps.CryptoSettings, field string) ([]byte, error) {
+	if crypto.Algorithm != types.EncryptionAlgA256GCM {
+		return nil, nil
+	}
+
+	additionalData := []interface{}{field}
+	for _, contextField := range crypto.ContextFields {
+		value, _ := json_map.GetValue(jsonMap, contextField, false)
+		if value == nil {
+			return nil, fmt.Errorf("crypto context field %v not found", contextField)
+		}
+		additionalData = append(additionalData, value)
+	}
+
+	return json.Marshal(additionalData)
+}
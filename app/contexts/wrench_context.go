@@ -22,6 +22,25 @@ type WrenchContext struct {
 	Endpoint       *api_settings.EndpointSettings
 	Tracer         trace.Tracer
 	Meter          metric.Meter
+	Consumer       *Consumer
+	TokenClaims    map[string]interface{}
+
+	cacheActionId  string
+	cacheActionKey string
+	cacheActionHit bool
+}
+
+type Consumer struct {
+	Id            string   `json:"consumerId"`
+	Roles         []string `json:"roles"`
+	RateLimitTier string   `json:"rateLimitTier"`
+}
+
+func (wrenchContext *WrenchContext) GetRateLimitTier() string {
+	if wrenchContext.Consumer == nil {
+		return ""
+	}
+	return wrenchContext.Consumer.RateLimitTier
 }
 
 func (wrenchContext *WrenchContext) SetHasError(span trace.Span, msg string, err error) {
@@ -42,6 +61,34 @@ func (wrenchContext *WrenchContext) SetHasCache() {
 	wrenchContext.HasCache = true
 }
 
+func (wrenchContext *WrenchContext) SetCacheAction(actionId string, key string, isHit bool) {
+	wrenchContext.cacheActionId = actionId
+	wrenchContext.cacheActionKey = key
+	wrenchContext.cacheActionHit = isHit
+
+	if isHit {
+		wrenchContext.HasCache = true
+	}
+}
+
+func (wrenchContext *WrenchContext) ReleaseCacheAction(actionId string) (key string, isHit bool) {
+	if len(actionId) == 0 || wrenchContext.cacheActionId != actionId {
+		return "", false
+	}
+
+	key = wrenchContext.cacheActionKey
+	isHit = wrenchContext.cacheActionHit
+
+	if isHit {
+		wrenchContext.HasCache = false
+	}
+
+	wrenchContext.cacheActionId = ""
+	wrenchContext.cacheActionKey = ""
+	wrenchContext.cacheActionHit = false
+	return key, isHit
+}
+
 func (wrenchContext *WrenchContext) SetHasError2() {
 	wrenchContext.HasError = true
 }
@@ -0,0 +1,261 @@
+package cross_funcs
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	JweAlgRsaOaep256 = "RSA-OAEP-256"
+	JweAlgEcdhEs     = "ECDH-ES"
+	jweEncA256Gcm    = "A256GCM"
+	gcmTagSize       = 16
+)
+
+type jweHeader struct {
+	Alg string  `json:"alg"`
+	Enc string  `json:"enc"`
+	Kid string  `json:"kid,omitempty"`
+	Cty string  `json:"cty,omitempty"`
+	Epk *jweEpk `json:"epk,omitempty"`
+}
+
+type jweEpk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ValidJweKey checks the key type expected by alg, ECDH-ES supports the curves of crypto/ecdh.
+func ValidJweKey(alg string, publicKey crypto.PublicKey) error {
+	switch alg {
+	case JweAlgRsaOaep256:
+		if _, ok := publicKey.(*rsa.PublicKey); !ok {
+			return fmt.Errorf("jwe %v requires a RSA key", alg)
+		}
+	case JweAlgEcdhEs:
+		ecdsaKey, ok := publicKey.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("jwe %v requires an EC key", alg)
+		}
+		if _, err := ecdsaKey.ECDH(); err != nil {
+			return fmt.Errorf("jwe %v: %w", alg, err)
+		}
+	default:
+		return fmt.Errorf("jwe algorithm %v not supported", alg)
+	}
+	return nil
+}
+
+// EncryptJwe returns the compact serialization (RFC 7516) with the content encrypted by A256GCM,
+// alg is RSA-OAEP-256 for RSA keys or ECDH-ES (direct key agreement) for EC keys.
+func EncryptJwe(alg string, publicKey crypto.PublicKey, kid string, contentType string, plaintext []byte) (string, error) {
+	header := jweHeader{Alg: alg, Enc: jweEncA256Gcm, Kid: kid, Cty: contentType}
+
+	var cek, encryptedKey []byte
+	var err error
+	switch alg {
+	case JweAlgRsaOaep256:
+		rsaKey, ok := publicKey.(*rsa.PublicKey)
+		if !ok {
+			return "", fmt.Errorf("jwe %v requires a RSA key", alg)
+		}
+		cek = make([]byte, 32)
+		if _, err = rand.Read(cek); err != nil {
+			return "", err
+		}
+		if encryptedKey, err = rsa.EncryptOAEP(sha256.New(), rand.Reader, rsaKey, cek, nil); err != nil {
+			return "", err
+		}
+
+	case JweAlgEcdhEs:
+		ecdsaKey, ok := publicKey.(*ecdsa.PublicKey)
+		if !ok {
+			return "", fmt.Errorf("jwe %v requires an EC key", alg)
+		}
+		if cek, header.Epk, err = ecdhEsSenderKey(ecdsaKey); err != nil {
+			return "", err
+		}
+
+	default:
+		return "", fmt.Errorf("jwe algorithm %v not supported", alg)
+	}
+
+	headerJson, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	protected := base64.RawURLEncoding.EncodeToString(headerJson)
+
+	sealed, err := EncryptAesGcm(cek, plaintext, []byte(protected))
+	if err != nil {
+		return "", err
+	}
+
+	iv, ciphertext, tag := sealed[:12], sealed[12:len(sealed)-gcmTagSize], sealed[len(sealed)-gcmTagSize:]
+
+	return strings.Join([]string{
+		protected,
+		base64.RawURLEncoding.EncodeToString(encryptedKey),
+		base64.RawURLEncoding.EncodeToString(iv),
+		base64.RawURLEncoding.EncodeToString(ciphertext),
+		base64.RawURLEncoding.EncodeToString(tag),
+	}, "."), nil
+}
+
+// DecryptJwe only accepts the alg expected, the header can't choose how the key is used.
+func DecryptJwe(alg string, privateKey crypto.Signer, compact string) ([]byte, error) {
+	parts, decoded, err := parseJweCompact(compact)
+	if err != nil {
+		return nil, err
+	}
+
+	header := new(jweHeader)
+	if err := json.Unmarshal(decoded[0], header); err != nil {
+		return nil, fmt.Errorf("jwe header: %w", err)
+	}
+
+	if header.Alg != alg || header.Enc != jweEncA256Gcm {
+		return nil, fmt.Errorf("jwe alg %v enc %v not expected", header.Alg, header.Enc)
+	}
+
+	var cek []byte
+	switch alg {
+	case JweAlgRsaOaep256:
+		rsaKey, ok := privateKey.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("jwe %v requires a RSA key", alg)
+		}
+		if cek, err = rsa.DecryptOAEP(sha256.New(), nil, rsaKey, decoded[1], nil); err != nil {
+			return nil, err
+		}
+
+	case JweAlgEcdhEs:
+		ecdsaKey, ok := privateKey.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("jwe %v requires an EC key", alg)
+		}
+		if cek, err = ecdhEsRecipientKey(ecdsaKey, header.Epk); err != nil {
+			return nil, err
+		}
+	}
+
+	return decryptJweContent(cek, parts, decoded)
+}
+
+func parseJweCompact(compact string) ([]string, [][]byte, error) {
+	parts := strings.Split(strings.TrimSpace(compact), ".")
+	if len(parts) != 5 {
+		return nil, nil, errors.New("jwe should have 5 parts")
+	}
+
+	decoded := make([][]byte, 5)
+	for i, part := range parts {
+		value, err := base64.RawURLEncoding.DecodeString(part)
+		if err != nil {
+			return nil, nil, fmt.Errorf("jwe part %v: %w", i, err)
+		}
+		decoded[i] = value
+	}
+
+	return parts, decoded, nil
+}
+
+// decryptJweContent opens iv || ciphertext || tag, the protected header as encoded is the additional data.
+func decryptJweContent(cek []byte, parts []string, decoded [][]byte) ([]byte, error) {
+	sealed := make([]byte, 0, len(decoded[2])+len(decoded[3])+len(decoded[4]))
+	sealed = append(append(append(sealed, decoded[2]...), decoded[3]...), decoded[4]...)
+	return DecryptAesGcm(cek, sealed, []byte(parts[0]))
+}
+
+func ecdhEsSenderKey(recipient *ecdsa.PublicKey) ([]byte, *jweEpk, error) {
+	recipientKey, err := recipient.ECDH()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ephemeral, err := recipientKey.Curve().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sharedSecret, err := ephemeral.ECDH(recipientKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// uncompressed point 0x04 || x || y
+	point := ephemeral.PublicKey().Bytes()
+	size := (len(point) - 1) / 2
+	epk := &jweEpk{
+		Kty: "EC",
+		Crv: recipient.Curve.Params().Name,
+		X:   base64.RawURLEncoding.EncodeToString(point[1 : 1+size]),
+		Y:   base64.RawURLEncoding.EncodeToString(point[1+size:]),
+	}
+
+	return concatKdf(sharedSecret, jweEncA256Gcm, nil, nil, 256), epk, nil
+}
+
+func ecdhEsRecipientKey(recipient *ecdsa.PrivateKey, epk *jweEpk) ([]byte, error) {
+	if epk == nil || epk.Crv != recipient.Curve.Params().Name {
+		return nil, errors.New("jwe epk missing or with another curve")
+	}
+
+	x, errX := base64.RawURLEncoding.DecodeString(epk.X)
+	y, errY := base64.RawURLEncoding.DecodeString(epk.Y)
+	if errX != nil || errY != nil {
+		return nil, errors.New("jwe epk invalid")
+	}
+
+	ephemeral := &ecdsa.PublicKey{Curve: recipient.Curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	ephemeralKey, err := ephemeral.ECDH()
+	if err != nil {
+		return nil, err
+	}
+
+	recipientKey, err := recipient.ECDH()
+	if err != nil {
+		return nil, err
+	}
+
+	sharedSecret, err := recipientKey.ECDH(ephemeralKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return concatKdf(sharedSecret, jweEncA256Gcm, nil, nil, 256), nil
+}
+
+// concatKdf is the Concat KDF of RFC 7518 4.6.2, with direct key agreement algorithmId is the enc.
+func concatKdf(sharedSecret []byte, algorithmId string, apu []byte, apv []byte, keyBits int) []byte {
+	otherInfo := binary.BigEndian.AppendUint32(nil, uint32(len(algorithmId)))
+	otherInfo = append(otherInfo, algorithmId...)
+	otherInfo = binary.BigEndian.AppendUint32(otherInfo, uint32(len(apu)))
+	otherInfo = append(otherInfo, apu...)
+	otherInfo = binary.BigEndian.AppendUint32(otherInfo, uint32(len(apv)))
+	otherInfo = append(otherInfo, apv...)
+	otherInfo = binary.BigEndian.AppendUint32(otherInfo, uint32(keyBits))
+
+	var key []byte
+	for round := uint32(1); len(key)*8 < keyBits; round++ {
+		digest := sha256.New()
+		digest.Write(binary.BigEndian.AppendUint32(nil, round))
+		digest.Write(sharedSecret)
+		digest.Write(otherInfo)
+		key = digest.Sum(key)
+	}
+
+	return key[:keyBits/8]
+}
@@ -0,0 +1,151 @@
+package cross_funcs
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestJweRoundTrip(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		alg        string
+		privateKey crypto.Signer
+	}{
+		{"RSA-OAEP-256", JweAlgRsaOaep256, rsaKey},
+		{"ECDH-ES P-256", JweAlgEcdhEs, p256Key},
+		{"ECDH-ES P-384", JweAlgEcdhEs, p384Key},
+	}
+
+	plaintext := []byte(`{"account":"12345-6","amount":100.5}`)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			compact, err := EncryptJwe(test.alg, test.privateKey.Public(), "key-1", "application/json", plaintext)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			decrypted, err := DecryptJwe(test.alg, test.privateKey, compact)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(decrypted) != string(plaintext) {
+				t.Errorf("expected %s, got %s", plaintext, decrypted)
+			}
+
+			// the protected header is the additional data, changing it fails the tag
+			parts := strings.Split(compact, ".")
+			header, _ := base64.RawURLEncoding.DecodeString(parts[0])
+			parts[0] = base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(string(header), `"kid":"key-1"`, `"kid":"key-2"`, 1)))
+			if _, err := DecryptJwe(test.alg, test.privateKey, strings.Join(parts, ".")); err == nil {
+				t.Error("a changed header should fail")
+			}
+		})
+	}
+}
+
+func TestDecryptJweRejectsAnotherAlg(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compact, err := EncryptJwe(JweAlgEcdhEs, privateKey.Public(), "", "", []byte("value"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := DecryptJwe(JweAlgRsaOaep256, privateKey, compact); err == nil {
+		t.Error("the alg of the header shouldn't be accepted when another is expected")
+	}
+}
+
+// RFC 7518 Appendix C, ECDH-ES with A128GCM, apu Alice and apv Bob
+func TestConcatKdfRfc7518AppendixC(t *testing.T) {
+	alicePrivate := decodeTestBase64(t, "0_NxaRPUMQoAJt50Gz8YiTr8gRTwyEaCumd-MToTmIo")
+	alicePublicX := decodeTestBase64(t, "gI0GAILBdu7T53akrFmMyGcsF3n5dO7MmwNBHKW5SV0")
+	alicePublicY := decodeTestBase64(t, "SLW_xSffzlPWrHEVI30DHM_4egVwt3NQqeUD7nMFpps")
+	bobPrivate := decodeTestBase64(t, "VEmDZpDXXK8p8N0Cndsxs924q6nS1RXFASRl6BfUqdw")
+	bobPublicX := decodeTestBase64(t, "weNJy2HscCSM6AEDTDg04biOvhFhyyWvOHQfeF_PxMQ")
+	bobPublicY := decodeTestBase64(t, "e8lnCO-AlStT-NJVX-crhB7QRYhiix03illJOVAOyck")
+
+	aliceKey, err := ecdh.P256().NewPrivateKey(alicePrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bobKey, err := ecdh.P256().NewPrivateKey(bobPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := append(append([]byte{4}, alicePublicX...), alicePublicY...); string(aliceKey.PublicKey().Bytes()) != string(expected) {
+		t.Fatal("alice public key doesn't match the private key")
+	}
+	if expected := append(append([]byte{4}, bobPublicX...), bobPublicY...); string(bobKey.PublicKey().Bytes()) != string(expected) {
+		t.Fatal("bob public key doesn't match the private key")
+	}
+
+	sharedSecret, err := aliceKey.ECDH(bobKey.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := concatKdf(sharedSecret, "A128GCM", []byte("Alice"), []byte("Bob"), 128)
+	if encoded := base64.RawURLEncoding.EncodeToString(key); encoded != "VqqN6vgjbSBcIijNcacQGg" {
+		t.Errorf("expected VqqN6vgjbSBcIijNcacQGg, got %v", encoded)
+	}
+}
+
+// RFC 7516 Appendix A.1, the content of the JWE is decrypted with the CEK of the example
+func TestDecryptJweContentRfc7516AppendixA1(t *testing.T) {
+	cek := []byte{177, 161, 244, 128, 84, 143, 225, 115, 63, 180, 3, 255, 107, 154, 212, 246,
+		138, 7, 110, 91, 112, 46, 34, 105, 47, 130, 203, 46, 122, 234, 64, 252}
+
+	compact := "eyJhbGciOiJSU0EtT0FFUCIsImVuYyI6IkEyNTZHQ00ifQ." +
+		"." +
+		"48V1_ALb6US04U3b." +
+		"5eym8TW_c8SuK0ltJ3rpYIzOeDQz7TALvtu6UG9oMo4vpzs9tX_EFShS8iB7j6jiSdiwkIr3ajwQzaBtQD_A." +
+		"XFBoMYUZodetZdvTiFvSkQ"
+
+	parts, decoded, err := parseJweCompact(compact)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext, err := decryptJweContent(cek, parts, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "The true sign of intelligence is not knowledge but imagination."; string(plaintext) != expected {
+		t.Errorf("expected %q, got %q", expected, plaintext)
+	}
+}
+
+func decodeTestBase64(t *testing.T, value string) []byte {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
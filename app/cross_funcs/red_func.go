@@ -1,6 +1,7 @@
 package cross_funcs
 
 import (
+	"sync"
 	"wrench/app/startup/connections"
 
 	"github.com/go-redsync/redsync/v4"
@@ -9,8 +10,11 @@ import (
 )
 
 var redsyncs map[string]*redsync.Redsync
+var redsyncsMutex sync.Mutex
 
 func GetRedsyncInstance(redisConnectionId string) *redsync.Redsync {
+	redsyncsMutex.Lock()
+	defer redsyncsMutex.Unlock()
 
 	if len(redsyncs) == 0 {
 		redsyncs = make(map[string]*redsync.Redsync)
@@ -30,3 +34,11 @@ func GetRedsyncInstance(redisConnectionId string) *redsync.Redsync {
 
 	return rs
 }
+
+// ResetRedsyncInstance drops the instance of the connection, the next lock uses the current client.
+func ResetRedsyncInstance(redisConnectionId string) {
+	redsyncsMutex.Lock()
+	defer redsyncsMutex.Unlock()
+
+	delete(redsyncs, redisConnectionId)
+}
@@ -0,0 +1,91 @@
+package cross_funcs
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"wrench/app/manifest/types"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Sign returns the raw signature, ECDSA signatures are r || s as in JWS and not ASN.1.
+func Sign(alg types.SignatureAlg, key crypto.Signer, data []byte) ([]byte, error) {
+	signingMethod := jwt.GetSigningMethod(string(alg))
+	if signingMethod == nil {
+		return nil, fmt.Errorf("signature algorithm %v not supported", alg)
+	}
+
+	signature, err := signingMethod.Sign(string(data), key)
+	if err != nil {
+		return nil, fmt.Errorf("sign %v: %w", alg, err)
+	}
+
+	return jwt.DecodeSegment(signature)
+}
+
+func Encode(encoding types.EncodingType, value []byte) []byte {
+	switch encoding {
+	case types.EncodingTypeBase64:
+		return []byte(base64.StdEncoding.EncodeToString(value))
+	case types.EncodingTypeBase64Url:
+		return []byte(base64.RawURLEncoding.EncodeToString(value))
+	case types.EncodingTypeHex:
+		return []byte(hex.EncodeToString(value))
+	}
+
+	return value
+}
+
+// Verify accepts ECDSA signatures as r || s and also ASN.1 DER, the format most partners outside JOSE send.
+func Verify(alg types.SignatureAlg, key crypto.PublicKey, data []byte, signature []byte) error {
+	signingMethod := jwt.GetSigningMethod(string(alg))
+	if signingMethod == nil {
+		return fmt.Errorf("signature algorithm %v not supported", alg)
+	}
+
+	if ecdsaKey, ok := key.(*ecdsa.PublicKey); ok {
+		if keyBytes := (ecdsaKey.Curve.Params().BitSize + 7) / 8; len(signature) != 2*keyBytes {
+			return verifyEcdsaASN1(alg, ecdsaKey, data, signature)
+		}
+	}
+
+	return signingMethod.Verify(string(data), jwt.EncodeSegment(signature), key)
+}
+
+func verifyEcdsaASN1(alg types.SignatureAlg, key *ecdsa.PublicKey, data []byte, signature []byte) error {
+	var digest []byte
+	switch alg {
+	case types.SignatureAlgES256:
+		sum := sha256.Sum256(data)
+		digest = sum[:]
+	case types.SignatureAlgES384:
+		sum := sha512.Sum384(data)
+		digest = sum[:]
+	default:
+		return fmt.Errorf("signature algorithm %v requires a RSA or Ed25519 key", alg)
+	}
+
+	if !ecdsa.VerifyASN1(key, digest, signature) {
+		return jwt.ErrECDSAVerification
+	}
+	return nil
+}
+
+func Decode(encoding types.EncodingType, value []byte) ([]byte, error) {
+	switch encoding {
+	case types.EncodingTypeBase64:
+		return base64.StdEncoding.DecodeString(string(value))
+	case types.EncodingTypeBase64Url:
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(string(value), "="))
+	case types.EncodingTypeHex:
+		return hex.DecodeString(string(value))
+	}
+
+	return value, nil
+}
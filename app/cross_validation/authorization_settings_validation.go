@@ -0,0 +1,83 @@
+package cross_validation
+
+import (
+	"fmt"
+	"wrench/app/manifest/api_settings"
+	"wrench/app/manifest/application_settings"
+	"wrench/app/manifest/validation"
+	"wrench/app/manifest_cross_funcs"
+)
+
+func authorizationCrossValidation(appSetting *application_settings.ApplicationSettings) validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if appSetting.Api == nil {
+		return result
+	}
+
+	for _, authorization := range appSetting.Api.GetAllAuthorizations() {
+		result.Append(authorizationSchemeCrossValidation(authorization))
+	}
+
+	if len(appSetting.Api.Authorizations) > 0 {
+		hasIds := toHasIdSlice(appSetting.Api.Authorizations)
+		duplicateIds := duplicateIdsValid(hasIds)
+
+		for _, id := range duplicateIds {
+			result.AddError(fmt.Sprintf("api.authorizations.id %v duplicated", id))
+		}
+	}
+
+	for _, endpoint := range appSetting.Api.Endpoints {
+		if endpoint.Authorization == nil {
+			// without api.authorization there isn't a default scheme, the endpoint would be open
+			if appSetting.Api.Authorization == nil && len(appSetting.Api.Authorizations) > 0 && !endpoint.EnableAnonymous {
+				result.AddError(fmt.Sprintf("api.endpoints[%v] should inform authorization.schemes or enableAnonymous when api.authorizations has no default api.authorization", endpoint.Route))
+			}
+			continue
+		}
+
+		for _, scheme := range endpoint.Authorization.Schemes {
+			if _, err := appSetting.Api.GetAuthorizationById(scheme); err != nil {
+				result.AddError(fmt.Sprintf("api.endpoints[%v].authorization.schemes %v don't exist in api.authorizations", endpoint.Route, scheme))
+			}
+		}
+	}
+
+	return result
+}
+
+func authorizationSchemeCrossValidation(authorization *api_settings.AuthorizationSettings) validation.ValidateResult {
+	var result validation.ValidateResult
+
+	apiKey := authorization.ApiKey
+	if apiKey != nil && apiKey.Redis != nil && len(apiKey.Redis.RedisConnectionId) > 0 {
+		_, err := manifest_cross_funcs.GetConnectionRedisSettingById(apiKey.Redis.RedisConnectionId)
+
+		if err != nil {
+			result.AddError(fmt.Sprintf("api.authorization[%v].apiKey.redis.redisConnectionId %v don't exist in connections.redis", authorization.Id, apiKey.Redis.RedisConnectionId))
+		}
+	}
+
+	hmac := authorization.Hmac
+	if hmac != nil && len(hmac.RedisConnectionId) > 0 {
+		_, err := manifest_cross_funcs.GetConnectionRedisSettingById(hmac.RedisConnectionId)
+
+		if err != nil {
+			result.AddError(fmt.Sprintf("api.authorization[%v].hmac.redisConnectionId %v don't exist in connections.redis", authorization.Id, hmac.RedisConnectionId))
+		}
+	}
+
+	introspection := authorization.Introspection
+	if introspection != nil && len(introspection.TokenCredentialId) > 0 {
+		tokenCredential, err := manifest_cross_funcs.GetTokenCredentialSettingById(introspection.TokenCredentialId)
+
+		if err != nil {
+			result.AddError(fmt.Sprintf("api.authorization[%v].introspection.tokenCredentialId %v don't exist in tokenCredentials", authorization.Id, introspection.TokenCredentialId))
+		} else if tokenCredential.ClientCredential == nil && tokenCredential.Basic == nil {
+			result.AddError(fmt.Sprintf("api.authorization[%v].introspection.tokenCredentialId %v should have clientCredential or basic", authorization.Id, introspection.TokenCredentialId))
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,67 @@
+package cross_validation
+
+import (
+	"fmt"
+	"wrench/app/manifest/application_settings"
+	"wrench/app/manifest/validation"
+	"wrench/app/manifest_cross_funcs"
+)
+
+func cacheCrossValidation(appSetting *application_settings.ApplicationSettings) validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if len(appSetting.Caches) > 0 {
+		for _, cache := range appSetting.Caches {
+			if len(cache.RedisConnectionId) > 0 {
+				_, err := manifest_cross_funcs.GetConnectionRedisSettingById(cache.RedisConnectionId)
+
+				if err != nil {
+					result.AddError(fmt.Sprintf("caches[%v].redisConnectionId %v don't exist in connections.redis", cache.Id, cache.RedisConnectionId))
+				}
+			}
+		}
+
+		hasIds := toHasIdSlice(appSetting.Caches)
+		duplicateIds := duplicateIdsValid(hasIds)
+
+		for _, id := range duplicateIds {
+			result.AddError(fmt.Sprintf("caches.id %v duplicated", id))
+		}
+	}
+
+	if appSetting.Api != nil {
+		for _, endpoint := range appSetting.Api.Endpoints {
+			if len(endpoint.CacheId) > 0 {
+				_, err := manifest_cross_funcs.GetCacheSettingById(endpoint.CacheId)
+
+				if err != nil {
+					result.AddError(fmt.Sprintf("api.endpoints[%v].cacheId %v don't exist in caches", endpoint.Route, endpoint.CacheId))
+				}
+			}
+		}
+	}
+
+	for _, action := range appSetting.Actions {
+		if action.Cache == nil {
+			continue
+		}
+
+		if len(action.Cache.CacheId) > 0 {
+			_, err := manifest_cross_funcs.GetCacheSettingById(action.Cache.CacheId)
+
+			if err != nil {
+				result.AddError(fmt.Sprintf("actions[%v].cache.cacheId %v don't exist in caches", action.Id, action.Cache.CacheId))
+			}
+		}
+
+		for _, invalidate := range action.Cache.Invalidate {
+			_, err := manifest_cross_funcs.GetCacheSettingById(invalidate.CacheId)
+
+			if err != nil {
+				result.AddError(fmt.Sprintf("actions[%v].cache.invalidate.cacheId %v don't exist in caches", action.Id, invalidate.CacheId))
+			}
+		}
+	}
+
+	return result
+}
@@ -2,6 +2,8 @@ package cross_validation
 
 import (
 	"fmt"
+	"wrench/app/manifest/action_settings"
+	"wrench/app/manifest/action_settings/http_settings"
 	"wrench/app/manifest/api_settings"
 	"wrench/app/manifest/application_settings"
 	"wrench/app/manifest/validation"
@@ -23,22 +25,45 @@ func endpointSettingsCrossValidation(appSetting *application_settings.Applicatio
 				}
 			}
 
-			if len(endpoint.RateLimitId) > 0 {
-				_, err := manifest_cross_funcs.GetRateLimitSettingById(endpoint.RateLimitId)
+			for _, rateLimitId := range endpoint.GetRateLimitIds() {
+				_, err := manifest_cross_funcs.GetRateLimitSettingById(rateLimitId)
 
 				if err != nil {
-					result.AddError(fmt.Sprintf("api.endpoints[%v].rateLimitId %v don't exist in rateLimits", endpoint.Route, endpoint.RateLimitId))
+					result.AddError(fmt.Sprintf("api.endpoints[%v].rateLimitId %v don't exist in rateLimits", endpoint.Route, rateLimitId))
 				}
 			}
 
-			if appSetting.Api.Authorization != nil && appSetting.Api.Authorization.Type == api_settings.HMACAuthorizationType {
-				if len(endpoint.Roles) > 0 ||
-					len(endpoint.Scopes) > 0 ||
-					len(endpoint.Claims) > 0 {
-					result.AddError(fmt.Sprintf("api.endpoints[%v] is using roles/scopes/claim which is not allowed for HMAC authorization", endpoint.Route))
+			if authorizations := appSetting.Api.GetEndpointAuthorizations(&endpoint); len(authorizations) > 0 {
+				supportsRoles, supportsScopesClaims := false, false
+
+				for _, authorization := range authorizations {
+					if authorization.Type != api_settings.HMACAuthorizationType {
+						supportsRoles = true
+					}
+
+					if authorization.Type == api_settings.JWKSAuthorizationType ||
+						authorization.Type == api_settings.IntrospectionAuthorizationType {
+						supportsScopesClaims = true
+					}
+				}
+
+				if !supportsRoles && len(endpoint.Roles) > 0 {
+					result.AddError(fmt.Sprintf("api.endpoints[%v] is using roles which is not allowed for HMAC authorization", endpoint.Route))
+				}
+
+				if !supportsScopesClaims && (len(endpoint.Scopes) > 0 || len(endpoint.Claims) > 0 || len(endpoint.ClaimRules) > 0) {
+					result.AddError(fmt.Sprintf("api.endpoints[%v] is using scopes/claim which is only allowed for jwks or introspection authorization", endpoint.Route))
 				}
 			}
 
+			if endpointUsesCallerTokenExchange(appSetting, &endpoint) && !endpointVerifiesToken(appSetting.Api.GetEndpointAuthorizations(&endpoint)) {
+				result.AddError(fmt.Sprintf("api.endpoints[%v] uses callerToken.mode exchange which requires a jwks or introspection authorization", endpoint.Route))
+			}
+
+			if endpoint.ClientCertificate != nil && appSetting.Api.Tls.GetClientAuth() == api_settings.TlsClientAuthNone {
+				result.AddError(fmt.Sprintf("api.endpoints[%v].clientCertificate requires api.tls.clientAuth optional or required", endpoint.Route))
+			}
+
 			if len(endpoint.ActionID) > 0 {
 				_, err := appSetting.GetActionById(endpoint.ActionID)
 				if err != nil {
@@ -50,3 +75,32 @@ func endpointSettingsCrossValidation(appSetting *application_settings.Applicatio
 
 	return result
 }
+
+func endpointUsesCallerTokenExchange(appSetting *application_settings.ApplicationSettings, endpoint *api_settings.EndpointSettings) bool {
+	actionIds := append([]string{endpoint.ActionID}, endpoint.FlowActionID...)
+
+	for _, actionId := range actionIds {
+		action, err := appSetting.GetActionById(actionId)
+		if err != nil || action.Type != action_settings.ActionTypeHttpRequest || action.Http == nil || action.Http.Request == nil {
+			continue
+		}
+
+		callerToken := action.Http.Request.CallerToken
+		if callerToken != nil && callerToken.Mode == http_settings.CallerTokenModeExchange {
+			return true
+		}
+	}
+
+	return false
+}
+
+// endpointVerifiesToken jwks and introspection are the authorizations verifying the caller token
+func endpointVerifiesToken(authorizations []*api_settings.AuthorizationSettings) bool {
+	for _, authorization := range authorizations {
+		if authorization.Type == api_settings.JWKSAuthorizationType ||
+			authorization.Type == api_settings.IntrospectionAuthorizationType {
+			return true
+		}
+	}
+	return false
+}
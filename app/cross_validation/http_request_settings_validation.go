@@ -2,10 +2,12 @@ package cross_validation
 
 import (
 	"fmt"
+	"wrench/app/clients/http_signing"
 	"wrench/app/manifest/action_settings"
 	"wrench/app/manifest/application_settings"
 	"wrench/app/manifest/validation"
 	"wrench/app/manifest_cross_funcs"
+	keys_load "wrench/app/startup/keys"
 )
 
 func httpRequestCrossValid(appSetting *application_settings.ApplicationSettings) validation.ValidateResult {
@@ -23,6 +25,30 @@ func httpRequestCrossValid(appSetting *application_settings.ApplicationSettings)
 					result.AddError(fmt.Sprintf("actions.http.request.tokenCredentialId %v don't exist in tokenCredentials", action.Http.Request.TokenCredentialId))
 				}
 			}
+
+			if action.Http.Request != nil && action.Http.Request.CallerToken != nil && len(action.Http.Request.CallerToken.TokenCredentialId) > 0 {
+				_, err := manifest_cross_funcs.GetTokenCredentialSettingById(action.Http.Request.CallerToken.TokenCredentialId)
+
+				if err != nil {
+					result.AddError(fmt.Sprintf("actions[%s].http.request.callerToken.tokenCredentialId %v don't exist in tokenCredentials", action.Id, action.Http.Request.CallerToken.TokenCredentialId))
+				}
+			}
+
+			if action.Http.Request != nil && action.Http.Request.Signing != nil {
+				messageSignature := action.Http.Request.Signing.HttpMessageSignature
+				if messageSignature != nil && len(messageSignature.KeyId) > 0 {
+					_, err := manifest_cross_funcs.GetPrivateKeyById(messageSignature.KeyId)
+
+					if err != nil {
+						result.AddError(fmt.Sprintf("actions[%s].http.request.signing.httpMessageSignature.keyId %v don't exist in keys", action.Id, messageSignature.KeyId))
+					} else if publicKey, err := keys_load.GetPublicKey(messageSignature.KeyId); err == nil {
+						// a key not loaded is already reported by the keys load
+						if err := http_signing.ValidHttpMessageSignatureKey(messageSignature.Algorithm, publicKey); err != nil {
+							result.AddError(fmt.Sprintf("actions[%s].http.request.signing.httpMessageSignature.keyId. The key %s can't be used: %v", action.Id, messageSignature.KeyId, err))
+						}
+					}
+				}
+			}
 		}
 	}
 
@@ -2,11 +2,14 @@ package cross_validation
 
 import (
 	"fmt"
+	"wrench/app/cross_funcs"
 	"wrench/app/manifest/action_settings"
 	"wrench/app/manifest/application_settings"
 	"wrench/app/manifest/key_settings"
+	"wrench/app/manifest/types"
 	"wrench/app/manifest/validation"
 	"wrench/app/manifest_cross_funcs"
+	keys_load "wrench/app/startup/keys"
 )
 
 func keyCrossValidation(appSetting *application_settings.ApplicationSettings) validation.ValidateResult {
@@ -14,9 +17,11 @@ func keyCrossValidation(appSetting *application_settings.ApplicationSettings) va
 
 	if len(appSetting.Keys) > 0 {
 		result.Append(keyIdDuplicated(appSetting.Keys))
+		result.Append(jwksKidDuplicated(appSetting.Keys))
 	}
 
 	result.Append(keyIdRefExist(appSetting))
+	result.Append(encryptionKeyRefValid(appSetting))
 
 	return result
 }
@@ -35,6 +40,25 @@ func keyIdDuplicated(settings []*key_settings.KeySettings) validation.ValidateRe
 	return result
 }
 
+// jwksKidDuplicated clients pick the key by kid, two published keys with the same kid break the verification.
+func jwksKidDuplicated(settings []*key_settings.KeySettings) validation.ValidateResult {
+	var result validation.ValidateResult
+
+	kids := make(map[string]bool)
+	for _, key := range settings {
+		if !key.PublishJwks {
+			continue
+		}
+
+		if kids[key.GetKid()] {
+			result.AddError(fmt.Sprintf("keys.kid %v duplicated in the jwks", key.GetKid()))
+		}
+		kids[key.GetKid()] = true
+	}
+
+	return result
+}
+
 func keyIdRefExist(appSetting *application_settings.ApplicationSettings) validation.ValidateResult {
 	var result validation.ValidateResult
 
@@ -52,5 +76,97 @@ func keyIdRefExist(appSetting *application_settings.ApplicationSettings) validat
 		}
 	}
 
+	for _, action := range getActionsByType(appSetting.Actions, action_settings.ActionTypeFuncJwt) {
+		if action.Func != nil && action.Func.Jwt != nil && len(action.Func.Jwt.KeyId) > 0 {
+			if _, err := manifest_cross_funcs.GetPrivateKeyById(action.Func.Jwt.KeyId); err != nil {
+				result.AddError(fmt.Sprintf("actions[%s].func.jwt.keyId. Don't exist keyId %s informed", action.Id, action.Func.Jwt.KeyId))
+			}
+		}
+	}
+
+	for _, action := range getActionsByType(appSetting.Actions, action_settings.ActionTypeFuncVerify) {
+		if action.Func != nil && action.Func.Verify != nil && len(action.Func.Verify.KeyId) > 0 {
+			if _, err := manifest_cross_funcs.GetKeyById(action.Func.Verify.KeyId); err != nil {
+				result.AddError(fmt.Sprintf("actions[%s].func.verify.keyId. Don't exist keyId %s informed", action.Id, action.Func.Verify.KeyId))
+			}
+		}
+	}
+
+	for _, tokenCredential := range appSetting.TokenCredentials {
+		var keyId string
+		if tokenCredential.PrivateKeyJwt != nil {
+			keyId = tokenCredential.PrivateKeyJwt.KeyId
+		} else if tokenCredential.JwtBearer != nil {
+			keyId = tokenCredential.JwtBearer.KeyId
+		}
+
+		if len(keyId) > 0 {
+			if _, err := manifest_cross_funcs.GetPrivateKeyById(keyId); err != nil {
+				result.AddError(fmt.Sprintf("tokenCredentials[%s].keyId. Don't exist keyId %s informed", tokenCredential.Id, keyId))
+			}
+		}
+	}
+
+	return result
+}
+
+func encryptionKeyRefValid(appSetting *application_settings.ApplicationSettings) validation.ValidateResult {
+	var result validation.ValidateResult
+
+	for _, action := range appSetting.Actions {
+		if action.Func == nil {
+			continue
+		}
+
+		if action.Type == action_settings.ActionTypeFuncEncrypt && action.Func.Encrypt != nil {
+			result.Append(encryptionKeyValid(fmt.Sprintf("actions[%s].func.encrypt", action.Id), action.Func.Encrypt.KeyId, action.Func.Encrypt.Algorithm, false))
+		}
+
+		if action.Type == action_settings.ActionTypeFuncDecrypt && action.Func.Decrypt != nil {
+			result.Append(encryptionKeyValid(fmt.Sprintf("actions[%s].func.decrypt", action.Id), action.Func.Decrypt.KeyId, action.Func.Decrypt.Algorithm, true))
+		}
+	}
+
+	if appSetting.Contract != nil {
+		for _, contractMap := range appSetting.Contract.Maps {
+			if contractMap.Encrypt != nil {
+				result.Append(encryptionKeyValid(fmt.Sprintf("contract.maps[%s].encrypt", contractMap.Id), contractMap.Encrypt.KeyId, contractMap.Encrypt.Algorithm, false))
+			}
+
+			if contractMap.Decrypt != nil {
+				result.Append(encryptionKeyValid(fmt.Sprintf("contract.maps[%s].decrypt", contractMap.Id), contractMap.Decrypt.KeyId, contractMap.Decrypt.Algorithm, true))
+			}
+		}
+	}
+
+	return result
+}
+
+// encryptionKeyValid A256GCM requires a secret key, RSA-OAEP-256 a RSA key and ECDH-ES an EC key, decrypt needs the private one.
+func encryptionKeyValid(path string, keyId string, algorithm types.EncryptionAlg, decrypt bool) validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if len(keyId) == 0 {
+		return result
+	}
+
+	key, err := manifest_cross_funcs.GetKeyById(keyId)
+	if err != nil {
+		result.AddError(fmt.Sprintf("%s.keyId. Don't exist keyId %s informed", path, keyId))
+	} else if algorithm == types.EncryptionAlgA256GCM && !key.IsSecret() {
+		result.AddError(fmt.Sprintf("%s.keyId. The key %s should have secretKeyBase64 to use %s", path, keyId, algorithm))
+	} else if algorithm != types.EncryptionAlgA256GCM && key.IsSecret() {
+		result.AddError(fmt.Sprintf("%s.keyId. The key %s should be a RSA or EC key to use %s", path, keyId, algorithm))
+	} else if algorithm != types.EncryptionAlgA256GCM && decrypt && key.IsPublicOnly() {
+		result.AddError(fmt.Sprintf("%s.keyId. The key %s should have the private key to decrypt", path, keyId))
+	} else if algorithm != types.EncryptionAlgA256GCM {
+		// a key not loaded is already reported by the keys load
+		if publicKey, err := keys_load.GetPublicKey(keyId); err == nil {
+			if err := cross_funcs.ValidJweKey(string(algorithm), publicKey); err != nil {
+				result.AddError(fmt.Sprintf("%s.keyId. The key %s can't be used: %v", path, keyId, err))
+			}
+		}
+	}
+
 	return result
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"wrench/app/manifest/application_settings"
 	"wrench/app/manifest/validation"
+	"wrench/app/manifest_cross_funcs"
 )
 
 func Valid() validation.ValidateResult {
@@ -18,6 +19,8 @@ func Valid() validation.ValidateResult {
 	result.Append(endpointSettingsCrossValidation(appSetting))
 	result.Append(dynamodbCrossValidation(appSetting))
 	result.Append(keyCrossValidation(appSetting))
+	result.Append(cacheCrossValidation(appSetting))
+	result.Append(authorizationCrossValidation(appSetting))
 
 	if len(appSetting.Actions) > 0 {
 		hasIds := toHasIdSlice(appSetting.Actions)
@@ -35,6 +38,14 @@ func Valid() validation.ValidateResult {
 		for _, id := range duplicateIds {
 			result.AddError(fmt.Sprintf("tokenCredentials.id %v duplicated", id))
 		}
+
+		for _, tokenCredential := range appSetting.TokenCredentials {
+			if tokenCredential.Store != nil && len(tokenCredential.Store.RedisConnectionId) > 0 {
+				if _, err := manifest_cross_funcs.GetConnectionRedisSettingById(tokenCredential.Store.RedisConnectionId); err != nil {
+					result.AddError(fmt.Sprintf("tokenCredentials[%v].store.redisConnectionId %v don't exist in connections.redis", tokenCredential.Id, tokenCredential.Store.RedisConnectionId))
+				}
+			}
+		}
 	}
 
 	if appSetting.Connections != nil && len(appSetting.Connections.Nats) > 0 {
@@ -30,6 +30,9 @@ var SnsPublishDuration metric.Float64Histogram
 var IdempDuration metric.Float64Histogram
 var RateLimitDuration metric.Float64Histogram
 var DynamoDbDuration metric.Float64Histogram
+var CacheDuration metric.Float64Histogram
+var TokenCredentialDuration metric.Float64Histogram
+var SecretRotationCounter metric.Int64Counter
 
 var LoggerProvider *sdklog.LoggerProvider
 var Logger log.Logger
@@ -43,6 +46,9 @@ func InitMetrics() {
 	IdempDuration, _ = Meter.Float64Histogram("gowrench_idempotency_duration_ms")
 	RateLimitDuration, _ = Meter.Float64Histogram("gowrench_rate_limit_duration_ms")
 	DynamoDbDuration, _ = Meter.Float64Histogram("gowrench_dynamodb_duration_ms")
+	CacheDuration, _ = Meter.Float64Histogram("gowrench_cache_duration_ms")
+	TokenCredentialDuration, _ = Meter.Float64Histogram("gowrench_token_credential_duration_ms")
+	SecretRotationCounter, _ = Meter.Int64Counter("gowrench_secret_rotations_total")
 }
 
 func InitLogger(lp *sdklog.LoggerProvider) {
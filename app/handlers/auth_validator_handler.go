@@ -4,49 +4,57 @@ import (
 	"context"
 	"net/http"
 	"strings"
+	"wrench/app"
 	"wrench/app/auth"
 	contexts "wrench/app/contexts"
 	"wrench/app/manifest/api_settings"
 )
 
 type AuthValidatorHandler struct {
-	Next             Handler
-	EndpointSettings *api_settings.EndpointSettings
-	ApiSettings      *api_settings.ApiSettings
+	Next                  Handler
+	EndpointSettings      *api_settings.EndpointSettings
+	AuthorizationSettings []*api_settings.AuthorizationSettings
 }
 
 func (handler *AuthValidatorHandler) Do(ctx context.Context, wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext) {
 
-	authorizationSettings := handler.ApiSettings.Authorization
 	endpointSettings := handler.EndpointSettings
 
-	if !endpointSettings.EnableAnonymous {
-
-		if authorizationSettings.Type == api_settings.JWKSAuthorizationType {
-			tokenString := wrenchContext.Request.Header.Get("Authorization")
-			if len(tokenString) == 0 {
-				handler.setHasError("Unauthorized", http.StatusUnauthorized, wrenchContext, bodyContext)
-			} else {
-				tokenString = strings.Replace(tokenString, "Bearer ", "", 1)
-
-				tokenIsValid := auth.JwksValidationAuthentication(ctx, tokenString, authorizationSettings)
-				if tokenIsValid {
-					tokenIsAuthorized := auth.JwksValidationAuthorization(tokenString, endpointSettings.Roles, endpointSettings.Scopes, endpointSettings.Claims)
-					if !tokenIsAuthorized {
-						handler.setHasError("Forbidden", http.StatusForbidden, wrenchContext, bodyContext)
-					}
-				} else {
-					handler.setHasError("Unauthorized", http.StatusUnauthorized, wrenchContext, bodyContext)
+	// no scheme resolved for an endpoint that isn't anonymous, it fails closed
+	if !endpointSettings.EnableAnonymous && len(handler.AuthorizationSettings) == 0 {
+		handler.setHasError("Unauthorized", http.StatusUnauthorized, wrenchContext, bodyContext)
+	}
+
+	if !endpointSettings.EnableAnonymous && len(handler.AuthorizationSettings) > 0 {
+		isAllOf := endpointSettings.Authorization.GetMode() == api_settings.AuthorizationModeAllOf
+		var failedStatusCode int
+
+		for _, authorizationSettings := range handler.AuthorizationSettings {
+			statusCode := handler.validate(ctx, authorizationSettings, wrenchContext, bodyContext)
+
+			if statusCode == http.StatusOK {
+				if !isAllOf {
+					failedStatusCode = 0
+					break
 				}
+				continue
+			}
+
+			// a scheme that authenticated but lacks permissions is a better answer than 401
+			if failedStatusCode != http.StatusForbidden {
+				failedStatusCode = statusCode
 			}
-		}
 
-		if authorizationSettings.Type == api_settings.HMACAuthorizationType {
-			isHMACValid := auth.HMACValidate(wrenchContext, bodyContext, authorizationSettings)
-			if !isHMACValid {
-				handler.setHasError("Unauthorized", http.StatusUnauthorized, wrenchContext, bodyContext)
+			if isAllOf {
+				break
 			}
 		}
+
+		if failedStatusCode == http.StatusForbidden {
+			handler.setHasError("Forbidden", http.StatusForbidden, wrenchContext, bodyContext)
+		} else if failedStatusCode != 0 {
+			handler.setHasError("Unauthorized", http.StatusUnauthorized, wrenchContext, bodyContext)
+		}
 	}
 
 	if handler.Next != nil {
@@ -54,6 +62,86 @@ func (handler *AuthValidatorHandler) Do(ctx context.Context, wrenchContext *cont
 	}
 }
 
+func (handler *AuthValidatorHandler) validate(ctx context.Context, authorizationSettings *api_settings.AuthorizationSettings, wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext) int {
+	endpointSettings := handler.EndpointSettings
+
+	if authorizationSettings.Type == api_settings.JWKSAuthorizationType {
+		tokenString := wrenchContext.Request.Header.Get("Authorization")
+		if len(tokenString) == 0 {
+			return http.StatusUnauthorized
+		}
+
+		tokenString = strings.Replace(tokenString, "Bearer ", "", 1)
+
+		claims, valid := auth.JwksValidationAuthentication(ctx, tokenString, authorizationSettings)
+		if !valid {
+			return http.StatusUnauthorized
+		}
+
+		if !auth.JwksValidationAuthorization(tokenString, authorizationSettings, endpointSettings) {
+			return http.StatusForbidden
+		}
+
+		wrenchContext.TokenClaims = claims
+		return http.StatusOK
+	}
+
+	if authorizationSettings.Type == api_settings.HMACAuthorizationType {
+		if !auth.HMACValidate(ctx, wrenchContext, bodyContext, authorizationSettings) {
+			return http.StatusUnauthorized
+		}
+
+		return http.StatusOK
+	}
+
+	if authorizationSettings.Type == api_settings.IntrospectionAuthorizationType {
+		tokenString := wrenchContext.Request.Header.Get("Authorization")
+		if len(tokenString) == 0 {
+			return http.StatusUnauthorized
+		}
+
+		tokenString = strings.Replace(tokenString, "Bearer ", "", 1)
+
+		claims, err := auth.IntrospectionValidation(ctx, tokenString, authorizationSettings.Introspection)
+		if err != nil {
+			app.LogError2("token introspection failed", err)
+			return http.StatusUnauthorized
+		}
+
+		if claims == nil {
+			return http.StatusUnauthorized
+		}
+
+		if !auth.ClaimsValidationAuthorization(claims, authorizationSettings, endpointSettings) {
+			return http.StatusForbidden
+		}
+
+		wrenchContext.TokenClaims = claims
+		return http.StatusOK
+	}
+
+	if authorizationSettings.Type == api_settings.ApiKeyAuthorizationType {
+		consumer, err := auth.ApiKeyValidate(ctx, wrenchContext, authorizationSettings.ApiKey)
+		if err != nil {
+			app.LogError2("api key validation failed", err)
+			return http.StatusUnauthorized
+		}
+
+		if consumer == nil {
+			return http.StatusUnauthorized
+		}
+
+		if !auth.ConsumerRolesValidation(consumer, endpointSettings.Roles) {
+			return http.StatusForbidden
+		}
+
+		wrenchContext.Consumer = consumer
+		return http.StatusOK
+	}
+
+	return http.StatusUnauthorized
+}
+
 func (handler *AuthValidatorHandler) SetNext(next Handler) {
 	handler.Next = next
 }
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"wrench/app"
+	contexts "wrench/app/contexts"
+	settings "wrench/app/manifest/action_settings"
+	"wrench/app/manifest/cache_settings"
+	"wrench/app/manifest_cross_funcs"
+)
+
+type CacheActionLookupHandler struct {
+	Next           Handler
+	ActionSettings *settings.ActionSettings
+	CacheSettings  *cache_settings.CacheSettings
+}
+
+func (handler *CacheActionLookupHandler) Do(ctx context.Context, wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext) {
+
+	if !wrenchContext.HasError &&
+		!wrenchContext.HasCache {
+
+		start := time.Now()
+		var failed bool
+		cacheStatus := cacheStatusMiss
+
+		spanDisplay := fmt.Sprintf("actions[%v].cache.%v", handler.ActionSettings.Id, handler.CacheSettings.Id)
+		ctxSpan, span := wrenchContext.GetSpan2(ctx, spanDisplay)
+
+		redisKey := getCacheRedisKey(handler.CacheSettings.Id, handler.CacheSettings.Keys, wrenchContext, bodyContext)
+		entry, err := readCacheEntry(ctxSpan, handler.CacheSettings, redisKey)
+
+		if err != nil {
+			span.RecordError(err)
+			app.LogError2(fmt.Sprintf("cache %v error to get key %v", handler.CacheSettings.Id, redisKey), err)
+			failed = true
+		} else if entry != nil && !entry.IsStale() {
+			bodyContext.SetBodyAction(handler.ActionSettings, entry.CurrentBodyByteArray)
+			bodyContext.HttpStatusCode = entry.HttpStatusCode
+			cacheStatus = cacheStatusHit
+		}
+
+		wrenchContext.SetCacheAction(handler.ActionSettings.Id, redisKey, cacheStatus == cacheStatusHit)
+
+		setCacheSpanAttributes(span, handler.CacheSettings, redisKey, cacheStatus)
+		span.End()
+		cacheMetricRecord(ctx, time.Since(start).Seconds()*1000, handler.CacheSettings.Id, cacheStatus, failed)
+	}
+
+	if handler.Next != nil {
+		handler.Next.Do(ctx, wrenchContext, bodyContext)
+	}
+}
+
+func (handler *CacheActionLookupHandler) SetNext(next Handler) {
+	handler.Next = next
+}
+
+type CacheActionStoreHandler struct {
+	Next           Handler
+	ActionSettings *settings.ActionSettings
+	CacheSettings  *cache_settings.CacheSettings
+}
+
+func (handler *CacheActionStoreHandler) Do(ctx context.Context, wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext) {
+
+	redisKey, isCacheHit := wrenchContext.ReleaseCacheAction(handler.ActionSettings.Id)
+
+	if len(redisKey) > 0 &&
+		!isCacheHit &&
+		!wrenchContext.HasError &&
+		!wrenchContext.HasCache &&
+		handler.CacheSettings.IsStatusCodeCacheable(bodyContext.HttpStatusCode) {
+
+		body := bodyContext.GetCurrentBody()
+		if handler.ActionSettings.ShouldPreserveBody() {
+			body, _ = bodyContext.GetBodyPreserved(handler.ActionSettings.Id)
+		}
+
+		entry := &cacheBodyContext{
+			CurrentBodyByteArray: body,
+			HttpStatusCode:       bodyContext.HttpStatusCode,
+		}
+
+		if err := writeCacheEntry(ctx, handler.CacheSettings, redisKey, entry); err != nil {
+			app.LogError2(fmt.Sprintf("cache %v error to set key %v", handler.CacheSettings.Id, redisKey), err)
+		}
+	}
+
+	if handler.Next != nil {
+		handler.Next.Do(ctx, wrenchContext, bodyContext)
+	}
+}
+
+func (handler *CacheActionStoreHandler) SetNext(next Handler) {
+	handler.Next = next
+}
+
+type CacheInvalidateHandler struct {
+	Next           Handler
+	ActionSettings *settings.ActionSettings
+}
+
+func (handler *CacheInvalidateHandler) Do(ctx context.Context, wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext) {
+
+	if !wrenchContext.HasError &&
+		!wrenchContext.HasCache {
+
+		spanDisplay := fmt.Sprintf("actions[%v].cache.invalidate", handler.ActionSettings.Id)
+		ctxSpan, span := wrenchContext.GetSpan2(ctx, spanDisplay)
+
+		for _, invalidate := range handler.ActionSettings.Cache.Invalidate {
+			cacheSettings, err := manifest_cross_funcs.GetCacheSettingById(invalidate.CacheId)
+			if err != nil {
+				continue
+			}
+
+			redisKey := getCacheRedisKey(cacheSettings.Id, invalidate.Keys, wrenchContext, bodyContext)
+			if err := deleteCacheEntry(ctxSpan, cacheSettings, redisKey); err != nil {
+				span.RecordError(err)
+				app.LogError2(fmt.Sprintf("cache %v error to invalidate key %v", cacheSettings.Id, redisKey), err)
+			}
+		}
+
+		span.End()
+	}
+
+	if handler.Next != nil {
+		handler.Next.Do(ctx, wrenchContext, bodyContext)
+	}
+}
+
+func (handler *CacheInvalidateHandler) SetNext(next Handler) {
+	handler.Next = next
+}
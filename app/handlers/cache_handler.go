@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	"wrench/app"
+	contexts "wrench/app/contexts"
+	"wrench/app/cross_funcs"
+	"wrench/app/manifest/api_settings"
+	"wrench/app/manifest/cache_settings"
+	"wrench/app/manifest_cross_funcs"
+	"wrench/app/stores"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const cacheHeaderName = "X-Cache"
+const cacheStatusHit = "HIT"
+const cacheStatusStale = "STALE"
+const cacheStatusMiss = "MISS"
+
+type CacheHandler struct {
+	Next             Handler
+	EndpointSettings *api_settings.EndpointSettings
+	CacheSettings    *cache_settings.CacheSettings
+}
+
+type cacheBodyContext struct {
+	CurrentBodyByteArray []byte
+	HttpStatusCode       int
+	ContentType          string
+	Headers              map[string]string
+	FreshUntil           int64
+}
+
+func (entry *cacheBodyContext) IsStale() bool {
+	return time.Now().UnixMilli() > entry.FreshUntil
+}
+
+func (handler *CacheHandler) Do(ctx context.Context, wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext) {
+
+	var redisKey string
+	var cacheStatus string
+	var failed bool
+
+	if !wrenchContext.HasError &&
+		!wrenchContext.HasCache {
+
+		start := time.Now()
+		spanDisplay := fmt.Sprintf("cache.%v", handler.CacheSettings.Id)
+		ctxSpan, span := wrenchContext.GetSpan2(ctx, spanDisplay)
+
+		redisKey = getCacheRedisKey(handler.CacheSettings.Id, handler.CacheSettings.Keys, wrenchContext, bodyContext)
+		entry, err := readCacheEntry(ctxSpan, handler.CacheSettings, redisKey)
+
+		if err != nil {
+			span.RecordError(err)
+			app.LogError2(fmt.Sprintf("cache %v error to get key %v", handler.CacheSettings.Id, redisKey), err)
+			failed = true
+			cacheStatus = cacheStatusMiss
+		} else if entry != nil {
+			requestBody := bodyContext.CurrentBodyByteArray
+
+			bodyContext.CurrentBodyByteArray = entry.CurrentBodyByteArray
+			mergeCachedHeaders(bodyContext, entry.Headers)
+			bodyContext.ContentType = entry.ContentType
+			bodyContext.HttpStatusCode = entry.HttpStatusCode
+			wrenchContext.SetHasCache()
+
+			cacheStatus = cacheStatusHit
+			if entry.IsStale() {
+				cacheStatus = cacheStatusStale
+				revalidateWrenchContext := handler.newRevalidateWrenchContext(ctx, wrenchContext)
+				go handler.revalidate(context.WithoutCancel(ctx), revalidateWrenchContext, requestBody, entry.ContentType, redisKey)
+			}
+		} else {
+			cacheStatus = cacheStatusMiss
+		}
+
+		bodyContext.SetHeader(cacheHeaderName, cacheStatus)
+		setCacheSpanAttributes(span, handler.CacheSettings, redisKey, cacheStatus)
+		span.End()
+		cacheMetricRecord(ctx, time.Since(start).Seconds()*1000, handler.CacheSettings.Id, cacheStatus, failed)
+	}
+
+	if handler.Next != nil {
+		handler.Next.Do(ctx, wrenchContext, bodyContext)
+	}
+
+	if cacheStatus == cacheStatusMiss &&
+		!wrenchContext.HasError &&
+		!wrenchContext.HasCache {
+		handler.store(ctx, bodyContext, redisKey)
+	}
+}
+
+// mergeCachedHeaders keeps the headers set by the previous handlers (cors, correlation, rate limit)
+func mergeCachedHeaders(bodyContext *contexts.BodyContext, headers map[string]string) {
+	for key, value := range headers {
+		if _, ok := bodyContext.Headers[key]; !ok {
+			bodyContext.SetHeader(key, value)
+		}
+	}
+}
+
+func (handler *CacheHandler) store(ctx context.Context, bodyContext *contexts.BodyContext, redisKey string) {
+	if !handler.CacheSettings.IsStatusCodeCacheable(bodyContext.HttpStatusCode) {
+		return
+	}
+
+	headers := make(map[string]string)
+	for key, value := range bodyContext.Headers {
+		if key != cacheHeaderName {
+			headers[key] = value
+		}
+	}
+
+	entry := &cacheBodyContext{
+		CurrentBodyByteArray: bodyContext.CurrentBodyByteArray,
+		Headers:              headers,
+		ContentType:          bodyContext.ContentType,
+		HttpStatusCode:       bodyContext.HttpStatusCode,
+	}
+
+	if err := writeCacheEntry(ctx, handler.CacheSettings, redisKey, entry); err != nil {
+		app.LogError2(fmt.Sprintf("cache %v error to set key %v", handler.CacheSettings.Id, redisKey), err)
+	}
+}
+
+func (handler *CacheHandler) newRevalidateWrenchContext(ctx context.Context, wrenchContext *contexts.WrenchContext) *contexts.WrenchContext {
+	var responseWriter http.ResponseWriter = newDiscardResponseWriter()
+
+	revalidateWrenchContext := *wrenchContext
+	revalidateWrenchContext.HasCache = false
+	revalidateWrenchContext.HasError = false
+	revalidateWrenchContext.ResponseWriter = &responseWriter
+	revalidateWrenchContext.Request = wrenchContext.Request.Clone(context.WithoutCancel(ctx))
+
+	return &revalidateWrenchContext
+}
+
+func (handler *CacheHandler) revalidate(ctx context.Context, wrenchContext *contexts.WrenchContext, requestBody []byte, contentType string, redisKey string) {
+	store, err := getCacheStore(handler.CacheSettings)
+	if err != nil {
+		return
+	}
+
+	lockKey := redisKey + ":revalidate"
+	lockOwner := []byte(app.GetInstanceID() + ":" + uuid.NewString())
+	locked, err := store.SetNX(ctx, lockKey, lockOwner, 30*time.Second)
+	if err != nil || !locked {
+		return
+	}
+	// a revalidation longer than the ttl lost the lock, it's released only while still owned
+	defer store.DeleteIfValue(ctx, lockKey, lockOwner)
+
+	revalidateBodyContext := new(contexts.BodyContext)
+	revalidateBodyContext.SetBody(requestBody)
+	revalidateBodyContext.ContentType = contentType
+	revalidateBodyContext.HttpStatusCode = 200
+
+	if handler.Next != nil {
+		handler.Next.Do(ctx, wrenchContext, revalidateBodyContext)
+	}
+
+	if !wrenchContext.HasError {
+		handler.store(ctx, revalidateBodyContext, redisKey)
+	}
+}
+
+func (handler *CacheHandler) SetNext(next Handler) {
+	handler.Next = next
+}
+
+func getCacheRedisKey(cacheId string, keys []string, wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext) string {
+	var keyTemp string
+
+	for _, keyRef := range keys {
+		value := contexts.GetCalculatedValue(keyRef, wrenchContext, bodyContext, nil)
+		keyTemp += fmt.Sprint(value)
+	}
+
+	hashValue := cross_funcs.GetHash(cacheId, sha256.New, []byte(keyTemp))
+	service := manifest_cross_funcs.GetService()
+	return fmt.Sprintf("%v:cache:%v:%v", service.Name, cacheId, hashValue)
+}
+
+func getCacheStore(cacheSettings *cache_settings.CacheSettings) (stores.KeyValueStore, error) {
+	return stores.GetKeyValueStore("caches:"+cacheSettings.Id, cacheSettings.GetBackend(), cacheSettings.RedisConnectionId, cacheSettings.MaxEntries)
+}
+
+func readCacheEntry(ctx context.Context, cacheSettings *cache_settings.CacheSettings, redisKey string) (*cacheBodyContext, error) {
+	store, err := getCacheStore(cacheSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	val, found, err := store.Get(ctx, redisKey)
+	if err != nil {
+		return nil, err
+	} else if !found {
+		return nil, nil
+	}
+
+	var entry cacheBodyContext
+	if err := json.Unmarshal(val, &entry); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+func writeCacheEntry(ctx context.Context, cacheSettings *cache_settings.CacheSettings, redisKey string, entry *cacheBodyContext) error {
+	store, err := getCacheStore(cacheSettings)
+	if err != nil {
+		return err
+	}
+
+	ttl := time.Duration(cacheSettings.TtlInSeconds) * time.Second
+	stale := time.Duration(cacheSettings.StaleInSeconds) * time.Second
+	entry.FreshUntil = time.Now().Add(ttl).UnixMilli()
+
+	redisValue, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return store.Set(ctx, redisKey, redisValue, ttl+stale)
+}
+
+func deleteCacheEntry(ctx context.Context, cacheSettings *cache_settings.CacheSettings, redisKey string) error {
+	store, err := getCacheStore(cacheSettings)
+	if err != nil {
+		return err
+	}
+
+	return store.Delete(ctx, redisKey)
+}
+
+func setCacheSpanAttributes(span trace.Span, cacheSettings *cache_settings.CacheSettings, key string, cacheStatus string) {
+	span.SetAttributes(
+		attribute.String("cache_id", cacheSettings.Id),
+		attribute.String("cache_key", key),
+		attribute.String("cache_status", cacheStatus),
+		attribute.String("cache_backend", string(cacheSettings.GetBackend())),
+		attribute.String("cache_redis_connection_id", cacheSettings.RedisConnectionId),
+	)
+}
+
+func cacheMetricRecord(ctx context.Context, duration float64, cacheId string, cacheStatus string, failed bool) {
+	app.CacheDuration.Record(ctx, duration,
+		metric.WithAttributes(
+			attribute.String("cache_id", cacheId),
+			attribute.String("cache_status", cacheStatus),
+			attribute.Bool("failed", failed),
+			attribute.String("instance", app.GetInstanceID()),
+		),
+	)
+}
+
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func newDiscardResponseWriter() *discardResponseWriter {
+	return &discardResponseWriter{header: make(http.Header)}
+}
+
+func (writer *discardResponseWriter) Header() http.Header {
+	return writer.header
+}
+
+func (writer *discardResponseWriter) Write(data []byte) (int, error) {
+	return len(data), nil
+}
+
+func (writer *discardResponseWriter) WriteHeader(statusCode int) {
+}
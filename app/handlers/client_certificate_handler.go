@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"wrench/app"
+	contexts "wrench/app/contexts"
+	"wrench/app/cross_funcs"
+	"wrench/app/manifest/api_settings"
+)
+
+type ClientCertificateHandler struct {
+	Next                      Handler
+	EndpointSettings          *api_settings.EndpointSettings
+	ClientCertificateSettings *api_settings.ClientCertificateSettings
+}
+
+func (handler *ClientCertificateHandler) Do(ctx context.Context, wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext) {
+
+	if !wrenchContext.HasError {
+		cert := contexts.GetClientCertificate(wrenchContext.Request)
+
+		if cert == nil {
+			app.LogWarning(fmt.Sprintf("client certificate is required to route %v", handler.EndpointSettings.Route))
+			handler.setHasError("Unauthorized", http.StatusUnauthorized, wrenchContext, bodyContext)
+		} else if !handler.isPinned(cert.Subject.String(), contexts.GetClientCertificateSans(cert)) {
+			app.LogWarning(fmt.Sprintf("client certificate %v is not allowed to route %v", cert.Subject.String(), handler.EndpointSettings.Route))
+			handler.setHasError("Forbidden", http.StatusForbidden, wrenchContext, bodyContext)
+		}
+	}
+
+	if handler.Next != nil {
+		handler.Next.Do(ctx, wrenchContext, bodyContext)
+	}
+}
+
+func (handler *ClientCertificateHandler) isPinned(subject string, sans []string) bool {
+	settings := handler.ClientCertificateSettings
+
+	if len(settings.Subjects) > 0 && !cross_funcs.ArrayStringContains(settings.Subjects, subject) {
+		return false
+	}
+
+	if len(settings.Sans) > 0 {
+		for _, san := range sans {
+			if cross_funcs.ArrayStringContains(settings.Sans, san) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+func (handler *ClientCertificateHandler) SetNext(next Handler) {
+	handler.Next = next
+}
+
+func (handler *ClientCertificateHandler) setHasError(msg string, httpStatusCode int, wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext) {
+	wrenchContext.SetHasError2()
+	bodyContext.ContentType = "text/plain"
+	bodyContext.HttpStatusCode = httpStatusCode
+	bodyContext.SetBody([]byte(msg))
+}
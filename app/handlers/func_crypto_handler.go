@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	contexts "wrench/app/contexts"
+	settings "wrench/app/manifest/action_settings"
+)
+
+type FuncCryptoHandler struct {
+	ActionSettings *settings.ActionSettings
+	Next           Handler
+}
+
+func (handler *FuncCryptoHandler) Do(ctx context.Context, wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext) {
+
+	if !wrenchContext.HasError &&
+		!wrenchContext.HasCache {
+
+		ctxSpan, span := wrenchContext.GetSpan(ctx, *handler.ActionSettings)
+		ctx = ctxSpan
+		defer span.End()
+
+		body, err := bodyContext.GetBody(handler.ActionSettings)
+		if err != nil {
+			wrenchContext.SetHasError3(span, err.Error(), err, 500, bodyContext)
+		} else if handler.ActionSettings.Type == settings.ActionTypeFuncEncrypt {
+			crypto := handler.ActionSettings.Func.Encrypt
+			encrypted, err := contexts.EncryptValue(crypto.KeyId, crypto.Algorithm, crypto.Kid, crypto.ContentType, body, nil)
+			if err != nil {
+				wrenchContext.SetHasError3(span, "failed to encrypt body", err, 500, bodyContext)
+			} else {
+				bodyContext.SetBodyAction(handler.ActionSettings, []byte(encrypted))
+			}
+		} else {
+			crypto := handler.ActionSettings.Func.Decrypt
+			decrypted, err := contexts.DecryptValue(crypto.KeyId, crypto.Algorithm, string(body), nil)
+			if err != nil {
+				wrenchContext.SetHasError3(span, "failed to decrypt body", err, 400, bodyContext)
+			} else {
+				bodyContext.SetBodyAction(handler.ActionSettings, decrypted)
+			}
+		}
+	}
+
+	if handler.Next != nil {
+		handler.Next.Do(ctx, wrenchContext, bodyContext)
+	}
+}
+
+func (handler *FuncCryptoHandler) SetNext(next Handler) {
+	handler.Next = next
+}
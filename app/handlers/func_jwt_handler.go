@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+	contexts "wrench/app/contexts"
+	settings "wrench/app/manifest/action_settings"
+	keys_load "wrench/app/startup/keys"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+type FuncJwtHandler struct {
+	ActionSettings *settings.ActionSettings
+	Next           Handler
+}
+
+func (handler *FuncJwtHandler) Do(ctx context.Context, wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext) {
+
+	if !wrenchContext.HasError &&
+		!wrenchContext.HasCache {
+
+		ctxSpan, span := wrenchContext.GetSpan(ctx, *handler.ActionSettings)
+		ctx = ctxSpan
+		defer span.End()
+
+		token, err := handler.createJwt(wrenchContext, bodyContext)
+		if err != nil {
+			wrenchContext.SetHasError3(span, err.Error(), err, 500, bodyContext)
+		} else {
+			bodyContext.SetBodyAction(handler.ActionSettings, []byte(token))
+		}
+	}
+
+	if handler.Next != nil {
+		handler.Next.Do(ctx, wrenchContext, bodyContext)
+	}
+}
+
+func (handler *FuncJwtHandler) createJwt(wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext) (string, error) {
+	jwtSetting := handler.ActionSettings.Func.Jwt
+
+	signer, err := keys_load.GetSigner(jwtSetting.KeyId)
+	if err != nil {
+		return "", err
+	}
+
+	signingMethod := jwt.GetSigningMethod(string(jwtSetting.Algorithm))
+	if signingMethod == nil {
+		return "", fmt.Errorf("action %s algorithm %s not supported", handler.ActionSettings.Id, jwtSetting.Algorithm)
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iat": now.Unix(),
+		"exp": now.Add(time.Duration(jwtSetting.GetExpiresInSeconds()) * time.Second).Unix(),
+	}
+	if jwtSetting.NotBefore {
+		claims["nbf"] = now.Unix()
+	}
+	if jwtSetting.Jti {
+		claims["jti"] = uuid.NewString()
+	}
+
+	for name, value := range contexts.GetCalculatedMap(jwtSetting.Claims, wrenchContext, bodyContext, handler.ActionSettings) {
+		claims[name] = value
+	}
+
+	token := jwt.NewWithClaims(signingMethod, claims)
+	kid := jwtSetting.Kid
+	if len(kid) == 0 {
+		kid = keys_load.GetKid(jwtSetting.KeyId)
+	}
+	token.Header["kid"] = kid
+
+	return token.SignedString(signer)
+}
+
+func (handler *FuncJwtHandler) SetNext(next Handler) {
+	handler.Next = next
+}
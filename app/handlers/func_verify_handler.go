@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	contexts "wrench/app/contexts"
+	"wrench/app/cross_funcs"
+	settings "wrench/app/manifest/action_settings"
+	keys_load "wrench/app/startup/keys"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var errSignatureMismatch = errors.New("signature verification failed")
+
+type FuncVerifyHandler struct {
+	ActionSettings *settings.ActionSettings
+	Next           Handler
+}
+
+func (handler *FuncVerifyHandler) Do(ctx context.Context, wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext) {
+
+	if !wrenchContext.HasError &&
+		!wrenchContext.HasCache {
+
+		ctxSpan, span := wrenchContext.GetSpan(ctx, *handler.ActionSettings)
+		ctx = ctxSpan
+		defer span.End()
+
+		body, err := bodyContext.GetBody(handler.ActionSettings)
+		if err != nil {
+			wrenchContext.SetHasError3(span, err.Error(), err, http.StatusInternalServerError, bodyContext)
+		} else if err = handler.verify(wrenchContext, bodyContext, body); errors.Is(err, errSignatureMismatch) {
+			wrenchContext.SetHasError3(span, errSignatureMismatch.Error(), err, handler.ActionSettings.Func.Verify.GetFailStatusCode(), bodyContext)
+		} else if err != nil {
+			wrenchContext.SetHasError3(span, err.Error(), err, http.StatusInternalServerError, bodyContext)
+		}
+	}
+
+	if handler.Next != nil {
+		handler.Next.Do(ctx, wrenchContext, bodyContext)
+	}
+}
+
+func (handler *FuncVerifyHandler) verify(wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext, body []byte) error {
+	verifySetting := handler.ActionSettings.Func.Verify
+
+	signatureValue := fmt.Sprint(contexts.GetCalculatedValue(verifySetting.Signature, wrenchContext, bodyContext, handler.ActionSettings))
+	signatureValue, hasPrefix := strings.CutPrefix(signatureValue, verifySetting.SignaturePrefix)
+	if len(signatureValue) == 0 || !hasPrefix {
+		return errSignatureMismatch
+	}
+
+	signature, err := cross_funcs.Decode(verifySetting.GetEncoding(), []byte(signatureValue))
+	if err != nil {
+		return errSignatureMismatch
+	}
+
+	if verifySetting.Hmac != nil {
+		key := contexts.GetCalculatedValue(verifySetting.Hmac.Key, wrenchContext, bodyContext, handler.ActionSettings)
+		mac := hmac.New(cross_funcs.GetHashFunc(verifySetting.Hmac.Alg), []byte(fmt.Sprint(key)))
+		mac.Write(body)
+
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return errSignatureMismatch
+		}
+		return nil
+	}
+
+	publicKey, err := keys_load.GetPublicKey(verifySetting.KeyId)
+	if err != nil {
+		return err
+	}
+
+	err = cross_funcs.Verify(verifySetting.Algorithm, publicKey, body, signature)
+	if errors.Is(err, jwt.ErrInvalidKeyType) {
+		return fmt.Errorf("key %s doesn't match the algorithm %s", verifySetting.KeyId, verifySetting.Algorithm)
+	} else if err != nil {
+		return fmt.Errorf("%w: %v", errSignatureMismatch, err)
+	}
+	return nil
+}
+
+func (handler *FuncVerifyHandler) SetNext(next Handler) {
+	handler.Next = next
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	action_settings "wrench/app/manifest/action_settings"
 	settings "wrench/app/manifest/application_settings"
+	"wrench/app/manifest/cache_settings"
 	"wrench/app/manifest_cross_funcs"
 	"wrench/app/startup/connections"
 )
@@ -25,27 +26,38 @@ func (chain *Chain) BuildChain(settings *settings.ApplicationSettings) {
 		return
 	}
 
-	hasAuthorization := settings.Api.HasAuthorization()
-
 	for _, endpoint := range settings.Api.Endpoints {
 		var firstHandler = new(HttpFirstHandler)
 
 		var currentHandler Handler
 		currentHandler = firstHandler
 
-		if hasAuthorization {
+		if endpoint.ClientCertificate != nil {
+			clientCertificateHandler := new(ClientCertificateHandler)
+			clientCertificateHandler.EndpointSettings = &endpoint
+			clientCertificateHandler.ClientCertificateSettings = endpoint.ClientCertificate
+
+			currentHandler.SetNext(clientCertificateHandler)
+			currentHandler = clientCertificateHandler
+		}
+
+		authorizations := settings.Api.GetEndpointAuthorizations(&endpoint)
+		if len(authorizations) > 0 || endpoint.ShouldConfigureAuthorization(settings.Api.HasAuthorization()) {
 			authValidatorHandler := new(AuthValidatorHandler)
 			authValidatorHandler.EndpointSettings = &endpoint
-			authValidatorHandler.ApiSettings = settings.Api
+			authValidatorHandler.AuthorizationSettings = authorizations
 
 			currentHandler.SetNext(authValidatorHandler)
 			currentHandler = authValidatorHandler
 		}
 
-		if len(endpoint.RateLimitId) > 0 {
+		if rateLimitIds := endpoint.GetRateLimitIds(); len(rateLimitIds) > 0 {
 			rateLimitHandler := new(RateLimitHandler)
 			rateLimitHandler.EndpointSettings = &endpoint
-			rateLimitHandler.RateLimitSettings, _ = manifest_cross_funcs.GetRateLimitSettingById(endpoint.RateLimitId)
+			for _, rateLimitId := range rateLimitIds {
+				rateLimitSettings, _ := manifest_cross_funcs.GetRateLimitSettingById(rateLimitId)
+				rateLimitHandler.RateLimitSettings = append(rateLimitHandler.RateLimitSettings, rateLimitSettings)
+			}
 
 			currentHandler.SetNext(rateLimitHandler)
 			currentHandler = rateLimitHandler
@@ -61,6 +73,15 @@ func (chain *Chain) BuildChain(settings *settings.ApplicationSettings) {
 			currentHandler = idempHandler
 		}
 
+		if len(endpoint.CacheId) > 0 {
+			cacheHandler := new(CacheHandler)
+			cacheHandler.EndpointSettings = &endpoint
+			cacheHandler.CacheSettings, _ = manifest_cross_funcs.GetCacheSettingById(endpoint.CacheId)
+
+			currentHandler.SetNext(cacheHandler)
+			currentHandler = cacheHandler
+		}
+
 		if len(endpoint.ActionID) > 0 {
 			action, _ := settings.GetActionById(endpoint.ActionID)
 			if action == nil {
@@ -95,6 +116,19 @@ func buildChainToAction(currentHandler Handler, settings *settings.ApplicationSe
 		currentHandler = httpContractMapHandler
 	}
 
+	var cacheSettings *cache_settings.CacheSettings
+	if action.Cache != nil && len(action.Cache.CacheId) > 0 {
+		cacheSettings, _ = manifest_cross_funcs.GetCacheSettingById(action.Cache.CacheId)
+	}
+
+	if cacheSettings != nil {
+		cacheLookupHandler := new(CacheActionLookupHandler)
+		cacheLookupHandler.ActionSettings = action
+		cacheLookupHandler.CacheSettings = cacheSettings
+		currentHandler.SetNext(cacheLookupHandler)
+		currentHandler = cacheLookupHandler
+	}
+
 	if action.Type == action_settings.ActionTypeHttpRequest {
 		httpRequestHadler := new(HttpRequestClientHandler)
 		httpRequestHadler.ActionSettings = action
@@ -165,6 +199,27 @@ func buildChainToAction(currentHandler Handler, settings *settings.ApplicationSe
 		currentHandler = funcGeneralHandler
 	}
 
+	if action.Type == action_settings.ActionTypeFuncJwt {
+		funcJwtHandler := new(FuncJwtHandler)
+		funcJwtHandler.ActionSettings = action
+		currentHandler.SetNext(funcJwtHandler)
+		currentHandler = funcJwtHandler
+	}
+
+	if action.Type == action_settings.ActionTypeFuncVerify {
+		funcVerifyHandler := new(FuncVerifyHandler)
+		funcVerifyHandler.ActionSettings = action
+		currentHandler.SetNext(funcVerifyHandler)
+		currentHandler = funcVerifyHandler
+	}
+
+	if action.Type == action_settings.ActionTypeFuncEncrypt || action.Type == action_settings.ActionTypeFuncDecrypt {
+		funcCryptoHandler := new(FuncCryptoHandler)
+		funcCryptoHandler.ActionSettings = action
+		currentHandler.SetNext(funcCryptoHandler)
+		currentHandler = funcCryptoHandler
+	}
+
 	if action.Type == action_settings.ActionTypeKafkaProducer {
 		kafkaProducerHandler := new(KafkaProducerHandler)
 		kafkaProducerHandler.ActionSettings = action
@@ -186,6 +241,21 @@ func buildChainToAction(currentHandler Handler, settings *settings.ApplicationSe
 		currentHandler = dynamoDbHandler
 	}
 
+	if cacheSettings != nil {
+		cacheStoreHandler := new(CacheActionStoreHandler)
+		cacheStoreHandler.ActionSettings = action
+		cacheStoreHandler.CacheSettings = cacheSettings
+		currentHandler.SetNext(cacheStoreHandler)
+		currentHandler = cacheStoreHandler
+	}
+
+	if action.Cache != nil && len(action.Cache.Invalidate) > 0 {
+		cacheInvalidateHandler := new(CacheInvalidateHandler)
+		cacheInvalidateHandler.ActionSettings = action
+		currentHandler.SetNext(cacheInvalidateHandler)
+		currentHandler = cacheInvalidateHandler
+	}
+
 	if action.Trigger != nil && action.Trigger.After != nil {
 		httpContractMapHandler := new(HttpContractMapHandler)
 
@@ -109,6 +109,16 @@ func (handler *HttpContractMapHandler) doDefault(wrenchContext *contexts.WrenchC
 		currentBodyContext, err = contexts.ApplyMathOperations(currentBodyContext, handler.ContractMap.Math)
 	}
 
+	if handler.ContractMap.Encrypt != nil && err == nil {
+		currentBodyContext, err = contexts.EncryptFields(currentBodyContext, handler.ContractMap.Encrypt)
+		errMsg = "Failed to encrypt values."
+	}
+
+	if handler.ContractMap.Decrypt != nil && err == nil {
+		currentBodyContext, err = contexts.DecryptFields(currentBodyContext, handler.ContractMap.Decrypt)
+		errMsg = "Failed to decrypt values."
+	}
+
 	return currentBodyContext, err, errMsg
 }
 
@@ -139,6 +149,16 @@ func (handler *HttpContractMapHandler) doSequency(wrenchContext *contexts.Wrench
 			}
 		} else if action == "math" {
 			currentBodyContext, err = contexts.ApplyMathOperations(currentBodyContext, handler.ContractMap.Math)
+		} else if action == "encrypt" {
+			currentBodyContext, err = contexts.EncryptFields(currentBodyContext, handler.ContractMap.Encrypt)
+			errMsg = "Failed to encrypt values."
+		} else if action == "decrypt" {
+			currentBodyContext, err = contexts.DecryptFields(currentBodyContext, handler.ContractMap.Decrypt)
+			errMsg = "Failed to decrypt values."
+		}
+
+		if err != nil {
+			break
 		}
 	}
 
@@ -24,11 +24,34 @@ func (httpFirst *HttpFirstHandler) Do(ctx context.Context, wrenchContext *contex
 	bodyContext.ContentType = "application/json"
 	bodyContext.HttpStatusCode = 200
 
+	requestContentType := wrenchContext.Request.Header.Get("Content-Type")
+	if !wrenchContext.HasError &&
+		!wrenchContext.Endpoint.IsProxy &&
+		len(body) > 0 &&
+		contexts.IsFormContentType(requestContentType) {
+		httpFirst.setFormBody(wrenchContext, bodyContext, body, requestContentType)
+	}
+
 	if httpFirst.Next != nil {
 		httpFirst.Next.Do(ctx, wrenchContext, bodyContext)
 	}
 }
 
+func (httpFirst *HttpFirstHandler) setFormBody(wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext, body []byte, requestContentType string) {
+	var formSettings = wrenchContext.Endpoint.Form
+
+	formMap, err := contexts.ParseFormBodyToMap(body, requestContentType, formSettings)
+	if err != nil {
+		bodyContext.ContentType = "text/plain"
+		bodyContext.HttpStatusCode = 400
+		bodyContext.SetBody([]byte("Failed to parse form request body"))
+		wrenchContext.SetHasError2()
+		return
+	}
+
+	bodyContext.SetMapObject(formMap)
+}
+
 func (httpFirst *HttpFirstHandler) SetNext(next Handler) {
 	httpFirst.Next = next
 }
@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"time"
 	"wrench/app"
@@ -12,50 +13,60 @@ import (
 	"wrench/app/cross_funcs"
 	"wrench/app/manifest/api_settings"
 	"wrench/app/manifest/rate_limit_settings"
-	"wrench/app/startup/connections"
+	"wrench/app/stores"
 
-	"github.com/go-redis/redis_rate/v10"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
 )
 
-var limiterStatic *redis_rate.Limiter
+const rateLimitHeaderLimit = "RateLimit-Limit"
+const rateLimitHeaderRemaining = "RateLimit-Remaining"
+const rateLimitHeaderReset = "RateLimit-Reset"
 
 type RateLimitHandler struct {
 	Next              Handler
 	EndpointSettings  *api_settings.EndpointSettings
-	RateLimitSettings *rate_limit_settings.RateLimitSettings
+	RateLimitSettings []*rate_limit_settings.RateLimitSettings
 }
 
 func (handler *RateLimitHandler) Do(ctx context.Context, wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext) {
 
 	if !wrenchContext.HasError &&
 		!wrenchContext.HasCache {
-		start := time.Now()
-		rtSettings := handler.RateLimitSettings
-
-		spanDisplay := fmt.Sprintf("rateLimit.%s", rtSettings.Id)
-		ctx, span := wrenchContext.GetSpan2(ctx, spanDisplay)
-		defer span.End()
-
-		key := handler.getKey(wrenchContext, bodyContext)
-		limit := handler.getRedisLimit()
-		limiter := handler.getRedislimiter()
-		res, err := limiter.Allow(ctx, key, limit)
-
-		if err != nil {
-			handler.setError(err, http.StatusInternalServerError, span, wrenchContext, bodyContext)
-		} else {
-			if res.Allowed == 0 {
-				bodyContext.SetHeader("Retry-After", fmt.Sprintf("%d", res.RetryAfter/time.Second))
-				handler.setError(errors.New("rate limit exceeded"), http.StatusTooManyRequests, span, wrenchContext, bodyContext)
+
+		var mostRestrictive *stores.RateLimitResult
+		var allowed []*stores.RateLimitResult
+
+		for _, rtSettings := range handler.RateLimitSettings {
+			if !rtSettings.AppliesToTier(wrenchContext.GetRateLimitTier()) {
+				continue
+			}
+
+			result, err := handler.allow(ctx, rtSettings, wrenchContext, bodyContext)
+			if err != nil || !result.Allowed {
+				// the rate limits passed before don't count a rejected request
+				refundRateLimits(ctx, allowed...)
+			}
+			if err != nil {
+				break
+			}
+
+			if mostRestrictive == nil ||
+				!result.Allowed ||
+				result.Remaining < mostRestrictive.Remaining {
+				mostRestrictive = result
 			}
+
+			if !result.Allowed {
+				break
+			}
+			allowed = append(allowed, result)
 		}
 
-		handler.setSpanAttributes(span, rtSettings.RedisConnectionId, key)
-		duration := time.Since(start).Seconds() * 1000
-		handler.metricRecord(ctx, duration)
+		if mostRestrictive != nil {
+			handler.setRateLimitHeaders(wrenchContext, mostRestrictive)
+		}
 	}
 
 	if handler.Next != nil {
@@ -63,36 +74,128 @@ func (handler *RateLimitHandler) Do(ctx context.Context, wrenchContext *contexts
 	}
 }
 
-func (handler *RateLimitHandler) getRedislimiter() *redis_rate.Limiter {
-	if limiterStatic == nil {
-		uClient, _ := connections.GetRedisConnection(handler.RateLimitSettings.RedisConnectionId)
-		limiterStatic = redis_rate.NewLimiter(uClient)
+func (handler *RateLimitHandler) allow(ctx context.Context, rtSettings *rate_limit_settings.RateLimitSettings, wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext) (*stores.RateLimitResult, error) {
+	start := time.Now()
+
+	spanDisplay := fmt.Sprintf("rateLimit.%s", rtSettings.Id)
+	ctx, span := wrenchContext.GetSpan2(ctx, spanDisplay)
+	defer span.End()
+
+	key := handler.getKey(rtSettings, wrenchContext, bodyContext)
+	result, err := handler.check(ctx, rtSettings, key)
+
+	if err != nil {
+		handler.setError(err, http.StatusInternalServerError, span, wrenchContext, bodyContext)
+	} else if !result.Allowed {
+		bodyContext.SetHeader("Retry-After", fmt.Sprint(durationToSeconds(result.RetryAfter)))
+		handler.setError(errors.New("rate limit exceeded"), http.StatusTooManyRequests, span, wrenchContext, bodyContext)
 	}
 
-	return limiterStatic
+	handler.setSpanAttributes(span, rtSettings, key)
+	duration := time.Since(start).Seconds() * 1000
+	handler.metricRecord(ctx, duration, rtSettings, result)
+
+	return result, err
 }
 
-func (handler *RateLimitHandler) getRedisLimit() redis_rate.Limit {
-	if handler.RateLimitSettings.RequestsPerSecond > 0 {
-		return redis_rate.Limit{
-			Rate:   handler.RateLimitSettings.RequestsPerSecond,
-			Burst:  handler.RateLimitSettings.BurstLimit,
+// check runs the rate and the quotas configured, returning the most restrictive result. A rejection
+// refunds the limits counted before it, the request didn't pass.
+func (handler *RateLimitHandler) check(ctx context.Context, rtSettings *rate_limit_settings.RateLimitSettings, key string) (*stores.RateLimitResult, error) {
+	limiter, err := stores.GetRateLimiter(rtSettings.GetBackend(), rtSettings.RedisConnectionId, rtSettings.MaxEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*stores.RateLimitResult
+
+	for _, allow := range handler.getLimiterChecks(rtSettings, limiter, key) {
+		result, err := allow(ctx)
+		if err != nil || !result.Allowed {
+			refundRateLimits(ctx, results...)
+			return result, err
+		}
+		results = append(results, result)
+	}
+
+	return stores.MostRestrictive(results), nil
+}
+
+type limiterCheck func(ctx context.Context) (*stores.RateLimitResult, error)
+
+func (handler *RateLimitHandler) getLimiterChecks(rtSettings *rate_limit_settings.RateLimitSettings, limiter stores.RateLimiter, key string) []limiterCheck {
+	var checks []limiterCheck
+
+	if rtSettings.HasRate() {
+		limit := handler.getLimit(rtSettings)
+
+		checks = append(checks, func(ctx context.Context) (*stores.RateLimitResult, error) {
+			if rtSettings.GetAlgorithm() == rate_limit_settings.RateLimitAlgorithmSlidingWindow {
+				return limiter.AllowSlidingWindow(ctx, key, limit)
+			}
+			return limiter.Allow(ctx, key, limit)
+		})
+	}
+
+	if rtSettings.Quota != nil {
+		now := time.Now().UTC()
+
+		if rtSettings.Quota.RequestsPerDay > 0 {
+			resetAt := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+			quotaKey := fmt.Sprintf("%v:quota:day:%v", key, now.Format("20060102"))
+
+			checks = append(checks, func(ctx context.Context) (*stores.RateLimitResult, error) {
+				return limiter.AllowQuota(ctx, quotaKey, rtSettings.Quota.RequestsPerDay, resetAt)
+			})
+		}
+
+		if rtSettings.Quota.RequestsPerMonth > 0 {
+			resetAt := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			quotaKey := fmt.Sprintf("%v:quota:month:%v", key, now.Format("200601"))
+
+			checks = append(checks, func(ctx context.Context) (*stores.RateLimitResult, error) {
+				return limiter.AllowQuota(ctx, quotaKey, rtSettings.Quota.RequestsPerMonth, resetAt)
+			})
+		}
+	}
+
+	return checks
+}
+
+func refundRateLimits(ctx context.Context, results ...*stores.RateLimitResult) {
+	for _, result := range results {
+		if err := result.Refund(ctx); err != nil {
+			app.LogError2("Error to refund the rate limit", err)
+		}
+	}
+}
+
+func (handler *RateLimitHandler) getLimit(rtSettings *rate_limit_settings.RateLimitSettings) stores.RateLimit {
+	if rtSettings.RequestsPerSecond > 0 {
+		return stores.RateLimit{
+			Rate:   rtSettings.RequestsPerSecond,
+			Burst:  rtSettings.BurstLimit,
 			Period: time.Second,
 		}
 	}
 
-	return redis_rate.Limit{
-		Rate:   handler.RateLimitSettings.RequestsPerMinute,
-		Burst:  handler.RateLimitSettings.BurstLimit,
+	if rtSettings.RequestsPerHour > 0 {
+		return stores.RateLimit{
+			Rate:   rtSettings.RequestsPerHour,
+			Burst:  rtSettings.BurstLimit,
+			Period: time.Hour,
+		}
+	}
+
+	return stores.RateLimit{
+		Rate:   rtSettings.RequestsPerMinute,
+		Burst:  rtSettings.BurstLimit,
 		Period: time.Minute,
 	}
 }
 
-func (handler *RateLimitHandler) getKey(wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext) string {
+func (handler *RateLimitHandler) getKey(rtSettings *rate_limit_settings.RateLimitSettings, wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext) string {
 	var keyTemp string
 
-	rtSettings := handler.RateLimitSettings
-
 	if rtSettings.RouteEnabled {
 		keyTemp = handler.EndpointSettings.Route
 	}
@@ -110,15 +213,40 @@ func (handler *RateLimitHandler) getKey(wrenchContext *contexts.WrenchContext, b
 	return cross_funcs.GetHash(handler.EndpointSettings.Route, sha256.New, keyArray)
 }
 
-func (handler *RateLimitHandler) metricRecord(ctx context.Context, duration float64) {
-	app.KafkaProducerDuration.Record(ctx, duration, metric.WithAttributes(
+// setRateLimitHeaders writes straight to the response writer so the headers survive
+// handlers that replace bodyContext.Headers.
+func (handler *RateLimitHandler) setRateLimitHeaders(wrenchContext *contexts.WrenchContext, result *stores.RateLimitResult) {
+	if wrenchContext.ResponseWriter == nil {
+		return
+	}
+
+	header := (*wrenchContext.ResponseWriter).Header()
+	header.Set(rateLimitHeaderLimit, fmt.Sprint(result.Limit))
+	header.Set(rateLimitHeaderRemaining, fmt.Sprint(result.Remaining))
+	header.Set(rateLimitHeaderReset, fmt.Sprint(durationToSeconds(result.ResetAfter)))
+}
+
+func durationToSeconds(duration time.Duration) int64 {
+	if duration <= 0 {
+		return 0
+	}
+	return int64(math.Ceil(duration.Seconds()))
+}
+
+func (handler *RateLimitHandler) metricRecord(ctx context.Context, duration float64, rtSettings *rate_limit_settings.RateLimitSettings, result *stores.RateLimitResult) {
+	app.RateLimitDuration.Record(ctx, duration, metric.WithAttributes(
+		attribute.String("rate_limit_id", rtSettings.Id),
+		attribute.Bool("allowed", result != nil && result.Allowed),
+		attribute.Bool("failed", result == nil),
 		attribute.String("instance", app.GetInstanceID()),
 	))
 }
 
-func (handler *RateLimitHandler) setSpanAttributes(span trace.Span, redisConnectionId string, key string) {
+func (handler *RateLimitHandler) setSpanAttributes(span trace.Span, rtSettings *rate_limit_settings.RateLimitSettings, key string) {
 	span.SetAttributes(
-		attribute.String("gowrench.connections.redis.id", redisConnectionId),
+		attribute.String("gowrench.connections.redis.id", rtSettings.RedisConnectionId),
+		attribute.String("rate.limit.backend", string(rtSettings.GetBackend())),
+		attribute.String("rate.limit.algorithm", string(rtSettings.GetAlgorithm())),
 		attribute.String("rate.limit.key", key),
 	)
 }
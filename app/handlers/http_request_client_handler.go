@@ -2,13 +2,19 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
 	"time"
 	"wrench/app"
+	"wrench/app/auth"
 	client "wrench/app/clients/http"
+	"wrench/app/clients/http_signing"
 	"wrench/app/contexts"
 	settings "wrench/app/manifest/action_settings"
+	"wrench/app/manifest/action_settings/http_settings"
 	"wrench/app/startup/token_credentials"
 
 	"go.opentelemetry.io/otel/attribute"
@@ -30,7 +36,12 @@ func (handler *HttpRequestClientHandler) Do(ctx context.Context, wrenchContext *
 		ctx, span := wrenchContext.GetSpan(ctx, *handler.ActionSettings)
 		defer span.End()
 
+		var formContentType string
 		body, _err := bodyContext.GetBody(handler.ActionSettings)
+		if _err == nil && handler.ActionSettings.Http.Request.Form != nil {
+			body, formContentType, _err = handler.getFormBody(wrenchContext, bodyContext)
+		}
+
 		if _err != nil {
 			wrenchContext.SetHasError3(span, "error getting body for http client request", _err, 500, bodyContext)
 		} else {
@@ -41,24 +52,16 @@ func (handler *HttpRequestClientHandler) Do(ctx context.Context, wrenchContext *
 			request.Url = handler.getUrl(wrenchContext, bodyContext)
 			request.Insecure = handler.ActionSettings.Http.Request.Insecure
 			request.SetHeaderTracestate(ctx)
-			request.SetHeaders(contexts.GetCalculatedMap(handler.ActionSettings.Http.Request.Headers, wrenchContext, bodyContext, handler.ActionSettings))
-
-			if len(handler.ActionSettings.Http.Request.TokenCredentialId) > 0 {
-				tokenData := token_credentials.GetTokenCredentialById(handler.ActionSettings.Http.Request.TokenCredentialId)
-				span.SetAttributes(attribute.String("gowrench.tokenCredentials.id", handler.ActionSettings.Http.Request.TokenCredentialId))
-				if tokenData != nil {
-					bearerToken := strings.Trim(fmt.Sprintf("%s %s", tokenData.TokenType, tokenData.AccessToken), " ")
-					if len(tokenData.HeaderName) == 0 {
-						request.SetHeader("Authorization", bearerToken)
-					} else {
-						request.SetHeader(tokenData.HeaderName, bearerToken)
-					}
-				}
+			if len(formContentType) > 0 {
+				request.SetHeader("Content-Type", formContentType)
 			}
+			request.SetHeaders(contexts.GetCalculatedMap(handler.ActionSettings.Http.Request.Headers, wrenchContext, bodyContext, handler.ActionSettings))
 
-			response, err := client.HttpClientDo(ctx, request)
+			response, err := handler.doRequest(ctx, span, wrenchContext, request)
 
-			if err != nil {
+			if errors.Is(err, errCallerTokenRequired) {
+				wrenchContext.SetHasError3(span, err.Error(), err, http.StatusUnauthorized, bodyContext)
+			} else if err != nil {
 				wrenchContext.SetHasError(span, "error to call server client", err)
 			} else {
 				if response.StatusCode > 399 {
@@ -68,16 +71,19 @@ func (handler *HttpRequestClientHandler) Do(ctx context.Context, wrenchContext *
 				bodyContext.SetBodyAction(handler.ActionSettings, response.Body)
 
 				bodyContext.HttpStatusCode = response.StatusCode
+				if response.HttpClientResponse != nil {
+					bodyContext.SetResponseHeaders(handler.ActionSettings.Id, response.HttpClientResponse.Header)
+				}
 				if handler.ActionSettings.Http.Response != nil {
 					bodyContext.SetHeaders(handler.ActionSettings.Http.Response.MapFixedHeaders)
 					bodyContext.SetHeaders(mapHttpResponseHeaders(response, handler.ActionSettings.Http.Response.MapResponseHeaders))
 				}
-			}
 
-			handler.setTraceSpanAttributes(span, response.StatusCode, request.Url, request.Method, request.Insecure)
+				handler.setTraceSpanAttributes(span, response.StatusCode, request.Url, request.Method, request.Insecure)
 
-			duration := time.Since(start).Seconds() * 1000
-			handler.metricRecord(ctx, duration, response.StatusCode, request.Url, request.Method)
+				duration := time.Since(start).Seconds() * 1000
+				handler.metricRecord(ctx, duration, response.StatusCode, request.Url, request.Method)
+			}
 		}
 	}
 
@@ -86,6 +92,99 @@ func (handler *HttpRequestClientHandler) Do(ctx context.Context, wrenchContext *
 	}
 }
 
+// doRequest sets the token credential and the signature, when the upstream answers 401 the token
+// is refreshed and the request retried once.
+func (handler *HttpRequestClientHandler) doRequest(ctx context.Context, span trace.Span, wrenchContext *contexts.WrenchContext, request *client.HttpClientRequestData) (*client.HttpClientResponseData, error) {
+	requestSettings := handler.ActionSettings.Http.Request
+
+	if requestSettings.CallerToken != nil {
+		if err := setCallerToken(ctx, wrenchContext, request, requestSettings.CallerToken); err != nil {
+			return nil, err
+		}
+	}
+
+	var tokenData *auth.TokenData
+	if len(requestSettings.TokenCredentialId) > 0 {
+		span.SetAttributes(attribute.String("gowrench.tokenCredentials.id", requestSettings.TokenCredentialId))
+
+		var err error
+		tokenData, err = token_credentials.GetTokenCredentialById(ctx, requestSettings.TokenCredentialId)
+		if err != nil {
+			return nil, err
+		}
+		setTokenCredentialHeader(request, tokenData)
+	}
+
+	if err := http_signing.SignRequest(ctx, request, requestSettings.Signing); err != nil {
+		return nil, err
+	}
+
+	response, err := client.HttpClientDo(ctx, request)
+	if err != nil || tokenData == nil || response.StatusCode != http.StatusUnauthorized {
+		return response, err
+	}
+
+	span.AddEvent("token credential rejected, refreshing")
+	tokenData, err = token_credentials.ForceRefreshTokenCredential(ctx, requestSettings.TokenCredentialId, tokenData)
+	if err != nil {
+		// the upstream 401 is answered, the refresh error is only recorded
+		span.RecordError(err)
+		app.LogError2(fmt.Sprintf("tokenCredential %v error to refresh the token rejected by the upstream", requestSettings.TokenCredentialId), err)
+		return response, nil
+	}
+	setTokenCredentialHeader(request, tokenData)
+
+	if err := http_signing.SignRequest(ctx, request, requestSettings.Signing); err != nil {
+		return nil, err
+	}
+
+	return client.HttpClientDo(ctx, request)
+}
+
+var errCallerTokenRequired = errors.New("caller bearer token is required")
+
+func setCallerToken(ctx context.Context, wrenchContext *contexts.WrenchContext, request *client.HttpClientRequestData, callerToken *http_settings.CallerTokenSettings) error {
+	authorization := wrenchContext.Request.Header.Get("Authorization")
+	if len(authorization) < 7 || !strings.EqualFold(authorization[:7], "Bearer ") {
+		return errCallerTokenRequired
+	}
+
+	subjectToken := strings.TrimSpace(authorization[7:])
+	if len(subjectToken) == 0 {
+		return errCallerTokenRequired
+	}
+
+	if callerToken.Mode == http_settings.CallerTokenModeForward {
+		request.SetHeader("Authorization", "Bearer "+subjectToken)
+		return nil
+	}
+
+	// exp is only trusted when the token was verified by the authorization, TokenClaims isn't set otherwise
+	var subjectExpiresAt time.Time
+	if wrenchContext.TokenClaims != nil {
+		if exp, err := strconv.ParseFloat(contexts.GetTokenClaims(wrenchContext, "exp"), 64); err == nil {
+			subjectExpiresAt = time.Unix(int64(exp), 0)
+		}
+	}
+
+	tokenData, err := token_credentials.ExchangeToken(ctx, callerToken, subjectToken, subjectExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	setTokenCredentialHeader(request, tokenData)
+	return nil
+}
+
+func setTokenCredentialHeader(request *client.HttpClientRequestData, tokenData *auth.TokenData) {
+	bearerToken := strings.Trim(fmt.Sprintf("%s %s", tokenData.TokenType, tokenData.AccessToken), " ")
+	if len(tokenData.HeaderName) == 0 {
+		request.SetHeader("Authorization", bearerToken)
+	} else {
+		request.SetHeader(tokenData.HeaderName, bearerToken)
+	}
+}
+
 func (handler *HttpRequestClientHandler) metricRecord(ctx context.Context, duration float64, statusCode int, url string, method string) {
 	app.HttpClientDurantion.Record(ctx, duration,
 		metric.WithAttributes(
@@ -153,6 +252,32 @@ func (handler *HttpRequestClientHandler) getUrl(wrenchContext *contexts.WrenchCo
 	}
 }
 
+func (handler *HttpRequestClientHandler) getFormBody(wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext) ([]byte, string, error) {
+	formSettings := handler.ActionSettings.Http.Request.Form
+	fields := contexts.GetCalculatedMap(formSettings.Fields, wrenchContext, bodyContext, handler.ActionSettings)
+
+	if formSettings.Type == http_settings.HttpRequestFormTypeUrlEncoded {
+		return contexts.BuildFormUrlEncodedBody(fields), contexts.ContentTypeFormUrlEncoded, nil
+	}
+
+	var files []*contexts.FormFile
+	for name, fileSettings := range formSettings.Files {
+		content := contexts.GetCalculatedValue(fileSettings.Content, wrenchContext, bodyContext, handler.ActionSettings)
+		var fileName string
+		if fileNameValue := contexts.GetCalculatedValue(fileSettings.FileName, wrenchContext, bodyContext, handler.ActionSettings); fileNameValue != nil {
+			fileName = fmt.Sprint(fileNameValue)
+		}
+
+		file, err := contexts.NewFormFile(name, content, fileName, fileSettings.ContentType, fileSettings.Base64)
+		if err != nil {
+			return nil, "", err
+		}
+		files = append(files, file)
+	}
+
+	return contexts.BuildMultipartBody(fields, files)
+}
+
 func mapHttpResponseHeaders(response *client.HttpClientResponseData, mapResponseHeader []string) map[string]string {
 
 	if mapResponseHeader == nil {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 	"wrench/app"
@@ -13,10 +14,8 @@ import (
 	"wrench/app/manifest/connection_settings"
 	"wrench/app/manifest/idemp_settings"
 	"wrench/app/manifest_cross_funcs"
-	"wrench/app/startup/connections"
+	"wrench/app/stores"
 
-	"github.com/go-redsync/redsync/v4"
-	"github.com/redis/go-redis/v9"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
@@ -29,19 +28,25 @@ type IdempHandler struct {
 	RedisSettings    *connection_settings.RedisConnectionSettings
 }
 
+const idempReplayedHeader = "Idempotent-Replayed"
+
 type idempBodyContext struct {
 	CurrentBodyByteArray []byte
 	HttpStatusCode       int
 	ContentType          string
 	Headers              map[string]string
+	Fingerprint          string
+	InFlight             bool
 }
 
 func (handler *IdempHandler) Do(ctx context.Context, wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext) {
 
 	var redisKeyData string
+	var fingerprint string
 	var failed bool
-	var mutex *redsync.Mutex
-	uClient, _ := connections.GetRedisConnection(handler.IdempSettings.RedisConnectionId)
+	var inFlightMarked bool
+	idempSettings := handler.IdempSettings
+	store, storeErr := stores.GetKeyValueStore("idemps:"+idempSettings.Id, idempSettings.GetBackend(), idempSettings.RedisConnectionId, idempSettings.MaxEntries)
 
 	spanDisplay := fmt.Sprintf("idemp.%v", handler.EndpointSettings.IdempId)
 	ctxSpan, span := wrenchContext.GetSpan2(ctx, spanDisplay)
@@ -51,51 +56,29 @@ func (handler *IdempHandler) Do(ctx context.Context, wrenchContext *contexts.Wre
 
 	if !wrenchContext.HasError {
 
-		keyValue := contexts.GetCalculatedValue(handler.IdempSettings.Key, wrenchContext, bodyContext, nil)
+		keyValue := contexts.GetCalculatedValue(idempSettings.Key, wrenchContext, bodyContext, nil)
 		valueArray := []byte(fmt.Sprint(keyValue))
 		hashValue := cross_funcs.GetHash(handler.EndpointSettings.Route, sha256.New, valueArray)
 
-		redisKeyLock := handler.getRedisKeyLock(handler.EndpointSettings.Route, hashValue)
 		redisKeyData = handler.getRedisKeyData(handler.EndpointSettings.Route, hashValue)
+		fingerprint = handler.getFingerprint(wrenchContext, bodyContext)
 
-		rd := cross_funcs.GetRedsyncInstance(handler.IdempSettings.RedisConnectionId)
-
-		mutex = rd.NewMutex(redisKeyLock,
-			redsync.WithTries(5),
-			redsync.WithRetryDelay(500*time.Millisecond),
-			redsync.WithExpiry(20*time.Second),
-		)
-
-		if err := mutex.Lock(); err != nil {
-			msg := "the distributed lock block request"
-			handler.setHasError(span, msg, err, 409, wrenchContext, bodyContext)
+		if storeErr != nil {
+			msg := fmt.Sprintf("idemp %v store unavailable", idempSettings.Id)
+			handler.setHasError(span, msg, storeErr, 500, wrenchContext, bodyContext)
 			failed = true
 		} else {
+			inFlightValue, _ := json.Marshal(idempBodyContext{Fingerprint: fingerprint, InFlight: true})
+			marked, err := store.SetNX(ctx, redisKeyData, inFlightValue, idempSettings.GetInFlightTimeout())
 
-			val, err := uClient.Get(ctx, redisKeyData).Result()
-			if err == redis.Nil {
-				// do nothing yet
-			} else if err != nil {
-				msg := fmt.Sprintf("redis client generic error to get key %v", redisKeyData)
+			if err != nil {
+				msg := fmt.Sprintf("redis client generic error to set key %v", redisKeyData)
 				handler.setHasError(span, msg, err, 500, wrenchContext, bodyContext)
 				failed = true
+			} else if marked {
+				inFlightMarked = true
 			} else {
-				var idempBody idempBodyContext
-				jsonErr := json.Unmarshal([]byte(val), &idempBody)
-
-				if jsonErr != nil {
-					msg := "idemp error to parse redis body"
-					handler.setHasError(span, msg, jsonErr, 500, wrenchContext, bodyContext)
-					failed = true
-				} else {
-
-					bodyContext.CurrentBodyByteArray = idempBody.CurrentBodyByteArray
-					bodyContext.Headers = idempBody.Headers
-					bodyContext.ContentType = idempBody.ContentType
-					bodyContext.HttpStatusCode = idempBody.HttpStatusCode
-
-					wrenchContext.SetHasCache()
-				}
+				failed = handler.replay(ctx, span, store, redisKeyData, fingerprint, wrenchContext, bodyContext)
 			}
 		}
 	}
@@ -104,46 +87,108 @@ func (handler *IdempHandler) Do(ctx context.Context, wrenchContext *contexts.Wre
 		handler.Next.Do(ctx, wrenchContext, bodyContext)
 	}
 
-	if !wrenchContext.HasError &&
-		!wrenchContext.HasCache {
+	if inFlightMarked {
+		if idempSettings.IsStatusCodeCacheable(bodyContext.HttpStatusCode) {
+			idempBody := idempBodyContext{
+				CurrentBodyByteArray: bodyContext.CurrentBodyByteArray,
+				Headers:              bodyContext.Headers,
+				ContentType:          bodyContext.ContentType,
+				HttpStatusCode:       bodyContext.HttpStatusCode,
+				Fingerprint:          fingerprint,
+			}
+
+			ttl := time.Duration(idempSettings.TtlInSeconds) * time.Second
 
-		idempBody := idempBodyContext{
-			CurrentBodyByteArray: bodyContext.CurrentBodyByteArray,
-			Headers:              bodyContext.Headers,
-			ContentType:          bodyContext.ContentType,
-			HttpStatusCode:       bodyContext.HttpStatusCode,
+			redisValue, _ := json.Marshal(idempBody)
+			if err := store.Set(ctx, redisKeyData, redisValue, ttl); err != nil {
+				app.LogError2(fmt.Sprintf("idemp %v error to set key %v", idempSettings.Id, redisKeyData), err)
+				failed = true
+			}
+		} else if err := store.Delete(ctx, redisKeyData); err != nil {
+			app.LogError2(fmt.Sprintf("idemp %v error to release key %v", idempSettings.Id, redisKeyData), err)
+			failed = true
 		}
+	}
 
-		ttl := time.Duration(handler.IdempSettings.TtlInSeconds) * time.Second
+	handler.setTraceSpanAttributes(span, redisKeyData, idempSettings)
+	duration := time.Since(start).Seconds() * 1000
+	handler.metricRecord(ctx, duration, failed)
+}
 
-		redisValue, _ := json.Marshal(idempBody)
-		err := uClient.Set(ctx, redisKeyData, string(redisValue), ttl).Err()
+// replay answers a request whose key was already seen: 422 when the payload differs,
+// 409 while the first request is still running, otherwise the stored response.
+func (handler *IdempHandler) replay(ctx context.Context, span trace.Span, store stores.KeyValueStore, redisKeyData string, fingerprint string, wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext) bool {
+	val, found, err := store.Get(ctx, redisKeyData)
+	if err != nil {
+		msg := fmt.Sprintf("redis client generic error to get key %v", redisKeyData)
+		handler.setHasError(span, msg, err, 500, wrenchContext, bodyContext)
+		return true
+	}
 
-		if err != nil {
-			failed = true
-		}
+	if !found {
+		// the first request finished without a cacheable response between SetNX and Get
+		bodyContext.SetHeader("Retry-After", fmt.Sprint(handler.IdempSettings.GetRetryAfterInSeconds()))
+		msg := "a request with the same idempotency key is in progress"
+		handler.setHasError(span, msg, errors.New(msg), 409, wrenchContext, bodyContext)
+		return false
 	}
 
-	if mutex != nil {
-		if ok, err := mutex.Unlock(); !ok || err != nil {
-			app.LogError2(fmt.Sprintf("could not release lock, redis key %v", redisKeyData), err)
+	var idempBody idempBodyContext
+	if err := json.Unmarshal(val, &idempBody); err != nil {
+		msg := "idemp error to parse redis body"
+		handler.setHasError(span, msg, err, 500, wrenchContext, bodyContext)
+		return true
+	}
+
+	if len(idempBody.Fingerprint) > 0 && idempBody.Fingerprint != fingerprint {
+		msg := "the idempotency key was already used with a different request payload"
+		handler.setHasError(span, msg, errors.New(msg), 422, wrenchContext, bodyContext)
+		return false
+	}
+
+	if idempBody.InFlight {
+		bodyContext.SetHeader("Retry-After", fmt.Sprint(handler.IdempSettings.GetRetryAfterInSeconds()))
+		msg := "a request with the same idempotency key is in progress"
+		handler.setHasError(span, msg, errors.New(msg), 409, wrenchContext, bodyContext)
+		return false
+	}
+
+	bodyContext.CurrentBodyByteArray = idempBody.CurrentBodyByteArray
+	bodyContext.Headers = idempBody.Headers
+	bodyContext.ContentType = idempBody.ContentType
+	bodyContext.HttpStatusCode = idempBody.HttpStatusCode
+	bodyContext.SetHeader(idempReplayedHeader, "true")
+
+	wrenchContext.SetHasCache()
+	return false
+}
+
+// getFingerprint defaults to the request method, uri and body.
+func (handler *IdempHandler) getFingerprint(wrenchContext *contexts.WrenchContext, bodyContext *contexts.BodyContext) string {
+	var fingerprintTemp string
+
+	if len(handler.IdempSettings.FingerprintKeys) > 0 {
+		for _, keyRef := range handler.IdempSettings.FingerprintKeys {
+			value := contexts.GetCalculatedValue(keyRef, wrenchContext, bodyContext, nil)
+			fingerprintTemp += fmt.Sprint(value)
 		}
+	} else {
+		fingerprintTemp = wrenchContext.Request.Method + wrenchContext.Request.RequestURI + bodyContext.GetBodyString()
 	}
 
-	handler.setTraceSpanAttributes(span, redisKeyData, handler.IdempSettings.Id, handler.IdempSettings.RedisConnectionId)
-	duration := time.Since(start).Seconds() * 1000
-	handler.metricRecord(ctx, duration, failed)
+	return cross_funcs.GetHash(handler.IdempSettings.Id, sha256.New, []byte(fingerprintTemp))
 }
 
 func (handler *IdempHandler) SetNext(next Handler) {
 	handler.Next = next
 }
 
-func (handler *IdempHandler) setTraceSpanAttributes(span trace.Span, key string, idempId string, redisConnectionId string) {
+func (handler *IdempHandler) setTraceSpanAttributes(span trace.Span, key string, idempSettings *idemp_settings.IdempSettings) {
 	span.SetAttributes(
 		attribute.String("idemp_key", key),
-		attribute.String("idemp_id", idempId),
-		attribute.String("idemp_redis_connection_id", redisConnectionId),
+		attribute.String("idemp_id", idempSettings.Id),
+		attribute.String("idemp_backend", string(idempSettings.GetBackend())),
+		attribute.String("idemp_redis_connection_id", idempSettings.RedisConnectionId),
 	)
 }
 
@@ -156,11 +201,6 @@ func (handler *IdempHandler) metricRecord(ctx context.Context, duration float64,
 	)
 }
 
-func (handler *IdempHandler) getRedisKeyLock(route string, hashValue string) string {
-	service := manifest_cross_funcs.GetService()
-	return fmt.Sprintf("%v:%v:%v:lock", service.Name, route, hashValue)
-}
-
 func (handler *IdempHandler) getRedisKeyData(route string, hashValue string) string {
 	service := manifest_cross_funcs.GetService()
 	return fmt.Sprintf("%v:%v:%v:data", service.Name, route, hashValue)
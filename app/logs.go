@@ -1,6 +1,7 @@
 package app
 
 import (
+	"fmt"
 	"log"
 	"time"
 
@@ -13,6 +14,7 @@ type WrenchErrorLog struct {
 }
 
 func LogInfo(msg string) {
+	msg = RedactSecrets(msg)
 	log.Print(msg)
 
 	if Logger != nil {
@@ -21,6 +23,7 @@ func LogInfo(msg string) {
 }
 
 func LogWarning(msg string) {
+	msg = RedactSecrets(msg)
 	log.Print(msg)
 
 	if Logger != nil {
@@ -29,10 +32,10 @@ func LogWarning(msg string) {
 }
 
 func LogError(err WrenchErrorLog) {
-	log.Print(err)
+	log.Print(RedactSecrets(fmt.Sprint(err)))
 
 	if Logger != nil {
-		Logger.Emit(GetContext(), getRecord(err.Message, otelLog.SeverityError))
+		Logger.Emit(GetContext(), getRecord(RedactSecrets(err.Message), otelLog.SeverityError))
 	}
 }
 
@@ -25,6 +25,7 @@ type ActionSettings struct {
 	Func     *func_settings.FuncSettings         `yaml:"func"`
 	DynamoDb *dynamodb_settings.DynamoDbSettings `yaml:"dynamodb"`
 	Body     *BodyActionSettings                 `yaml:"body"`
+	Cache    *CacheActionSettings                `yaml:"cache"`
 }
 
 func (setting *ActionSettings) GetId() string {
@@ -45,6 +46,10 @@ const (
 	ActionTypeFuncVarContext        ActionType = "funcVarContext"
 	ActionTypeFuncStringConcatenate ActionType = "funcStringConcatenate"
 	ActionTypeFuncGeneral           ActionType = "funcGeneral"
+	ActionTypeFuncJwt               ActionType = "funcJwt"
+	ActionTypeFuncVerify            ActionType = "funcVerify"
+	ActionTypeFuncEncrypt           ActionType = "funcEncrypt"
+	ActionTypeFuncDecrypt           ActionType = "funcDecrypt"
 	ActionTypeDynamoDb              ActionType = "dynamodb"
 )
 
@@ -70,6 +75,10 @@ func (setting *ActionSettings) Valid() validation.ValidateResult {
 			setting.Type == ActionTypeFuncVarContext ||
 			setting.Type == ActionTypeFuncStringConcatenate ||
 			setting.Type == ActionTypeFuncGeneral ||
+			setting.Type == ActionTypeFuncJwt ||
+			setting.Type == ActionTypeFuncVerify ||
+			setting.Type == ActionTypeFuncEncrypt ||
+			setting.Type == ActionTypeFuncDecrypt ||
 			setting.Type == ActionTypeDynamoDb) == false {
 
 			var msg = fmt.Sprintf("actions[%s].type should contain valid value", setting.Id)
@@ -113,6 +122,10 @@ func (setting *ActionSettings) Valid() validation.ValidateResult {
 		result.AppendValidable(setting.DynamoDb)
 	}
 
+	if setting.Cache != nil {
+		result.AppendValidable(setting.Cache)
+	}
+
 	result.Append(setting.ActionTypeKafkaProducerValid())
 	result.Append(setting.checkTypes())
 
@@ -170,10 +183,30 @@ func (setting *ActionSettings) checkTypes() validation.ValidateResult {
 		result.AddError(fmt.Sprintf("actions[%v].dynamodb is required when type is %v", setting.Id, setting.Type))
 	}
 
+	if setting.Type == ActionTypeFuncJwt && setting.Func != nil && setting.Func.Jwt == nil {
+		result.AddError(fmt.Sprintf("actions[%v].func.jwt is required when type is %v", setting.Id, setting.Type))
+	}
+
+	if setting.Type == ActionTypeFuncVerify && setting.Func != nil && setting.Func.Verify == nil {
+		result.AddError(fmt.Sprintf("actions[%v].func.verify is required when type is %v", setting.Id, setting.Type))
+	}
+
+	if setting.Type == ActionTypeFuncEncrypt && setting.Func != nil && setting.Func.Encrypt == nil {
+		result.AddError(fmt.Sprintf("actions[%v].func.encrypt is required when type is %v", setting.Id, setting.Type))
+	}
+
+	if setting.Type == ActionTypeFuncDecrypt && setting.Func != nil && setting.Func.Decrypt == nil {
+		result.AddError(fmt.Sprintf("actions[%v].func.decrypt is required when type is %v", setting.Id, setting.Type))
+	}
+
 	if (setting.Type == ActionTypeFuncVarContext ||
 		setting.Type == ActionTypeFuncStringConcatenate ||
 		setting.Type == ActionTypeFuncHash ||
-		setting.Type == ActionTypeFuncGeneral) && setting.Func == nil {
+		setting.Type == ActionTypeFuncGeneral ||
+		setting.Type == ActionTypeFuncJwt ||
+		setting.Type == ActionTypeFuncVerify ||
+		setting.Type == ActionTypeFuncEncrypt ||
+		setting.Type == ActionTypeFuncDecrypt) && setting.Func == nil {
 
 		if setting.Func == nil {
 			result.AddError(fmt.Sprintf("actions[%v].func is required when type is %v", setting.Id, setting.Type))
@@ -0,0 +1,36 @@
+package action_settings
+
+import (
+	"fmt"
+	"wrench/app/manifest/validation"
+)
+
+type CacheActionSettings struct {
+	CacheId    string                     `yaml:"cacheId"`
+	Invalidate []*CacheInvalidateSettings `yaml:"invalidate"`
+}
+
+type CacheInvalidateSettings struct {
+	CacheId string   `yaml:"cacheId"`
+	Keys    []string `yaml:"keys"`
+}
+
+func (setting *CacheActionSettings) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if len(setting.CacheId) == 0 && len(setting.Invalidate) == 0 {
+		result.AddError("actions.cache.cacheId or actions.cache.invalidate is required")
+	}
+
+	for _, invalidate := range setting.Invalidate {
+		if len(invalidate.CacheId) == 0 {
+			result.AddError("actions.cache.invalidate.cacheId is required")
+		}
+
+		if len(invalidate.Keys) == 0 {
+			result.AddError(fmt.Sprintf("actions.cache.invalidate[%v].keys is required", invalidate.CacheId))
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,31 @@
+package func_settings
+
+import (
+	"fmt"
+	"slices"
+	"wrench/app/manifest/types"
+	"wrench/app/manifest/validation"
+)
+
+// FuncCryptoSettings encrypts or decrypts the whole action body, A256GCM bodies are
+// base64(nonce || ciphertext) and the JWE ones the compact serialization.
+type FuncCryptoSettings struct {
+	KeyId       string              `yaml:"keyId"`
+	Algorithm   types.EncryptionAlg `yaml:"algorithm"`
+	Kid         string              `yaml:"kid"`
+	ContentType string              `yaml:"contentType"`
+}
+
+func (setting FuncCryptoSettings) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if len(setting.KeyId) == 0 {
+		result.AddError("actions.func.encrypt/decrypt.keyId is required")
+	}
+
+	if !slices.Contains(types.SupportedEncryptionAlgorithms, setting.Algorithm) {
+		result.AddError(fmt.Sprintf("actions.func.encrypt/decrypt.algorithm should be one of %v", types.SupportedEncryptionAlgorithms))
+	}
+
+	return result
+}
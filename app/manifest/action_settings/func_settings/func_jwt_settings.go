@@ -0,0 +1,47 @@
+package func_settings
+
+import (
+	"fmt"
+	"slices"
+	"wrench/app/manifest/types"
+	"wrench/app/manifest/validation"
+)
+
+const defaultJwtExpiresInSeconds = 300
+
+// FuncJwtSettings builds a compact JWT, claims are expressions and iat is always set,
+// exp, nbf and jti are helpers and a configured claim with the same name wins.
+type FuncJwtSettings struct {
+	KeyId            string             `yaml:"keyId"`
+	Kid              string             `yaml:"kid"`
+	Algorithm        types.SignatureAlg `yaml:"algorithm"`
+	Claims           map[string]string  `yaml:"claims"`
+	ExpiresInSeconds int                `yaml:"expiresInSeconds"`
+	NotBefore        bool               `yaml:"notBefore"`
+	Jti              bool               `yaml:"jti"`
+}
+
+func (setting FuncJwtSettings) GetExpiresInSeconds() int {
+	if setting.ExpiresInSeconds == 0 {
+		return defaultJwtExpiresInSeconds
+	}
+	return setting.ExpiresInSeconds
+}
+
+func (setting FuncJwtSettings) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if len(setting.KeyId) == 0 {
+		result.AddError("actions.func.jwt.keyId is required")
+	}
+
+	if !slices.Contains(supportedSignatureAlgorithms, setting.Algorithm) {
+		result.AddError(fmt.Sprintf("actions.func.jwt.algorithm should be one of %v", supportedSignatureAlgorithms))
+	}
+
+	if setting.ExpiresInSeconds < 0 {
+		result.AddError("actions.func.jwt.expiresInSeconds can't be negative")
+	}
+
+	return result
+}
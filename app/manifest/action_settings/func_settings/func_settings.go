@@ -15,6 +15,10 @@ const (
 type FuncSettings struct {
 	Hash        *FuncHashSettings      `yaml:"hash"`
 	Sign        *FuncSignatureSettings `yaml:"sign"`
+	Jwt         *FuncJwtSettings       `yaml:"jwt"`
+	Verify      *FuncVerifySettings    `yaml:"verify"`
+	Encrypt     *FuncCryptoSettings    `yaml:"encrypt"`
+	Decrypt     *FuncCryptoSettings    `yaml:"decrypt"`
 	Vars        map[string]string      `yaml:"vars"`
 	Concatenate []string               `yaml:"concatenate"`
 	Command     FuncGeneralType        `yaml:"command"`
@@ -31,6 +35,22 @@ func (setting FuncSettings) Valid() validation.ValidateResult {
 		result.AppendValidable(setting.Sign)
 	}
 
+	if setting.Jwt != nil {
+		result.AppendValidable(setting.Jwt)
+	}
+
+	if setting.Verify != nil {
+		result.AppendValidable(setting.Verify)
+	}
+
+	if setting.Encrypt != nil {
+		result.AppendValidable(setting.Encrypt)
+	}
+
+	if setting.Decrypt != nil {
+		result.AppendValidable(setting.Decrypt)
+	}
+
 	if len(setting.Command) > 0 {
 		if string(setting.Command) == "{{"+string(FuncTypeTimestampMilli)+"}}" ||
 			string(setting.Command) == "{{"+string(FuncTypeBase64Encode)+"}}" ||
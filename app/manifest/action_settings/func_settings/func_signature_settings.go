@@ -1,13 +1,40 @@
 package func_settings
 
 import (
+	"fmt"
+	"slices"
 	"wrench/app/manifest/types"
 	"wrench/app/manifest/validation"
 )
 
+var supportedSignatureAlgorithms = []types.SignatureAlg{
+	types.SignatureAlgRS256, types.SignatureAlgRS512, types.SignatureAlgPS256,
+	types.SignatureAlgES256, types.SignatureAlgES384, types.SignatureAlgEdDSA,
+}
+
+var supportedSignatureEncodings = []types.EncodingType{
+	types.EncodingTypeRaw, types.EncodingTypeBase64, types.EncodingTypeBase64Url, types.EncodingTypeHex,
+}
+
 type FuncSignatureSettings struct {
-	KeyId     string        `yaml:"keyId"`
-	Algorithm types.HashAlg `yaml:"algorithm"`
+	KeyId     string             `yaml:"keyId"`
+	Algorithm string             `yaml:"algorithm"`
+	Encoding  types.EncodingType `yaml:"encoding"`
+}
+
+// GetAlgorithm keeps SHA-256 working, it was the name of RS256 before the JWA names.
+func (setting FuncSignatureSettings) GetAlgorithm() types.SignatureAlg {
+	if setting.Algorithm == string(types.HashAlgSHA256) {
+		return types.SignatureAlgRS256
+	}
+	return types.SignatureAlg(setting.Algorithm)
+}
+
+func (setting FuncSignatureSettings) GetEncoding() types.EncodingType {
+	if len(setting.Encoding) == 0 {
+		return types.EncodingTypeRaw
+	}
+	return setting.Encoding
 }
 
 func (setting FuncSignatureSettings) Valid() validation.ValidateResult {
@@ -18,6 +45,12 @@ func (setting FuncSignatureSettings) Valid() validation.ValidateResult {
 
 	if len(setting.Algorithm) == 0 {
 		result.AddError("actions.func.sign.algorithm is required")
+	} else if !slices.Contains(supportedSignatureAlgorithms, setting.GetAlgorithm()) {
+		result.AddError(fmt.Sprintf("actions.func.sign.algorithm should be one of %v", supportedSignatureAlgorithms))
+	}
+
+	if !slices.Contains(supportedSignatureEncodings, setting.GetEncoding()) {
+		result.AddError(fmt.Sprintf("actions.func.sign.encoding should be one of %v", supportedSignatureEncodings))
 	}
 
 	return result
@@ -0,0 +1,69 @@
+package func_settings
+
+import (
+	"fmt"
+	"slices"
+	"wrench/app/manifest/types"
+	"wrench/app/manifest/validation"
+)
+
+const defaultVerifyFailStatusCode = 401
+
+// FuncVerifySettings checks the signature expression against the action body, with the public
+// part of keyId or with a HMAC. A mismatch stops the chain with failStatusCode.
+type FuncVerifySettings struct {
+	Signature       string             `yaml:"signature"`
+	SignaturePrefix string             `yaml:"signaturePrefix"`
+	Encoding        types.EncodingType `yaml:"encoding"`
+	KeyId           string             `yaml:"keyId"`
+	Algorithm       types.SignatureAlg `yaml:"algorithm"`
+	Hmac            *FuncHashSettings  `yaml:"hmac"`
+	FailStatusCode  int                `yaml:"failStatusCode"`
+}
+
+func (setting FuncVerifySettings) GetEncoding() types.EncodingType {
+	if len(setting.Encoding) == 0 {
+		return types.EncodingTypeBase64
+	}
+	return setting.Encoding
+}
+
+func (setting FuncVerifySettings) GetFailStatusCode() int {
+	if setting.FailStatusCode == 0 {
+		return defaultVerifyFailStatusCode
+	}
+	return setting.FailStatusCode
+}
+
+func (setting FuncVerifySettings) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if len(setting.Signature) == 0 {
+		result.AddError("actions.func.verify.signature is required")
+	}
+
+	if setting.Hmac != nil {
+		if len(setting.KeyId) > 0 {
+			result.AddError("actions.func.verify.keyId can't be used with actions.func.verify.hmac")
+		}
+		result.AppendValidable(setting.Hmac)
+	} else {
+		if len(setting.KeyId) == 0 {
+			result.AddError("actions.func.verify.keyId or actions.func.verify.hmac is required")
+		}
+
+		if !slices.Contains(supportedSignatureAlgorithms, setting.Algorithm) {
+			result.AddError(fmt.Sprintf("actions.func.verify.algorithm should be one of %v", supportedSignatureAlgorithms))
+		}
+	}
+
+	if !slices.Contains(supportedSignatureEncodings, setting.GetEncoding()) {
+		result.AddError(fmt.Sprintf("actions.func.verify.encoding should be one of %v", supportedSignatureEncodings))
+	}
+
+	if setting.FailStatusCode != 0 && (setting.FailStatusCode < 400 || setting.FailStatusCode > 599) {
+		result.AddError("actions.func.verify.failStatusCode should be between 400 and 599")
+	}
+
+	return result
+}
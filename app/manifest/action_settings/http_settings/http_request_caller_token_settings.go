@@ -0,0 +1,56 @@
+package http_settings
+
+import (
+	"fmt"
+	"wrench/app/manifest/validation"
+)
+
+type CallerTokenMode string
+
+const (
+	CallerTokenModeForward  CallerTokenMode = "forward"
+	CallerTokenModeExchange CallerTokenMode = "exchange"
+)
+
+// CallerTokenSettings calls the upstream on behalf of the caller. forward sends the inbound bearer token,
+// exchange trades it (RFC 8693) at the authEndpoint of tokenCredentialId, which also authenticates the gateway.
+type CallerTokenSettings struct {
+	Mode               CallerTokenMode `yaml:"mode"`
+	TokenCredentialId  string          `yaml:"tokenCredentialId"`
+	Audience           string          `yaml:"audience"`
+	Resource           string          `yaml:"resource"`
+	Scope              string          `yaml:"scope"`
+	RequestedTokenType string          `yaml:"requestedTokenType"`
+	MaxEntries         int             `yaml:"maxEntries"`
+}
+
+func (setting *CallerTokenSettings) GetRequestedTokenType() string {
+	if len(setting.RequestedTokenType) == 0 {
+		return "urn:ietf:params:oauth:token-type:access_token"
+	}
+	return setting.RequestedTokenType
+}
+
+func (setting *CallerTokenSettings) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if setting.Mode != CallerTokenModeForward && setting.Mode != CallerTokenModeExchange {
+		result.AddError(fmt.Sprintf("actions.http.request.callerToken.mode should contain valid value (%v or %v)", CallerTokenModeForward, CallerTokenModeExchange))
+	}
+
+	if setting.Mode == CallerTokenModeExchange {
+		if len(setting.TokenCredentialId) == 0 {
+			result.AddError("actions.http.request.callerToken.tokenCredentialId is required when mode is exchange")
+		}
+
+		if len(setting.Audience) == 0 && len(setting.Resource) == 0 && len(setting.Scope) == 0 {
+			result.AddError("actions.http.request.callerToken should inform audience, resource or scope when mode is exchange")
+		}
+	}
+
+	if setting.MaxEntries < 0 {
+		result.AddError("actions.http.request.callerToken.maxEntries can't be negative")
+	}
+
+	return result
+}
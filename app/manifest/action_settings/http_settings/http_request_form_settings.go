@@ -0,0 +1,57 @@
+package http_settings
+
+import (
+	"fmt"
+	"wrench/app/manifest/validation"
+)
+
+type HttpRequestFormType string
+
+const (
+	HttpRequestFormTypeUrlEncoded HttpRequestFormType = "urlencoded"
+	HttpRequestFormTypeMultipart  HttpRequestFormType = "multipart"
+)
+
+type HttpRequestFormSettings struct {
+	Type   HttpRequestFormType                     `yaml:"type"`
+	Fields map[string]string                       `yaml:"fields"`
+	Files  map[string]*HttpRequestFormFileSettings `yaml:"files"`
+}
+
+type HttpRequestFormFileSettings struct {
+	Content     string `yaml:"content"`
+	FileName    string `yaml:"fileName"`
+	ContentType string `yaml:"contentType"`
+	Base64      bool   `yaml:"base64"`
+}
+
+func (setting *HttpRequestFormSettings) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if len(setting.Type) == 0 {
+		result.AddError("actions.http.request.form.type is required")
+	} else {
+		if (setting.Type == HttpRequestFormTypeUrlEncoded ||
+			setting.Type == HttpRequestFormTypeMultipart) == false {
+			result.AddError(fmt.Sprintf("actions.http.request.form.type should contain valid value (%v or %v)", HttpRequestFormTypeUrlEncoded, HttpRequestFormTypeMultipart))
+		}
+	}
+
+	if len(setting.Fields) == 0 && len(setting.Files) == 0 {
+		result.AddError("actions.http.request.form.fields or actions.http.request.form.files is required")
+	}
+
+	if len(setting.Files) > 0 {
+		if setting.Type != HttpRequestFormTypeMultipart {
+			result.AddError("actions.http.request.form.files can be configured only when type is multipart")
+		}
+
+		for name, file := range setting.Files {
+			if file == nil || len(file.Content) == 0 {
+				result.AddError(fmt.Sprintf("actions.http.request.form.files[%v].content is required", name))
+			}
+		}
+	}
+
+	return result
+}
@@ -6,11 +6,14 @@ import (
 )
 
 type HttpRequestSetting struct {
-	Method            types.HttpMethod  `yaml:"method"`
-	Url               string            `yaml:"url"`
-	Headers           map[string]string `yaml:"headers"`
-	TokenCredentialId string            `yaml:"tokenCredentialId"`
-	Insecure          bool              `yaml:"insecure"`
+	Method            types.HttpMethod            `yaml:"method"`
+	Url               string                      `yaml:"url"`
+	Headers           map[string]string           `yaml:"headers"`
+	TokenCredentialId string                      `yaml:"tokenCredentialId"`
+	Insecure          bool                        `yaml:"insecure"`
+	Form              *HttpRequestFormSettings    `yaml:"form"`
+	Signing           *HttpRequestSigningSettings `yaml:"signing"`
+	CallerToken       *CallerTokenSettings        `yaml:"callerToken"`
 }
 
 func (setting *HttpRequestSetting) Valid() validation.ValidateResult {
@@ -33,5 +36,21 @@ func (setting *HttpRequestSetting) Valid() validation.ValidateResult {
 		result.AddError("actions.http.request.url is required")
 	}
 
+	if setting.Form != nil {
+		result.AppendValidable(setting.Form)
+	}
+
+	if setting.Signing != nil {
+		result.AppendValidable(setting.Signing)
+	}
+
+	if setting.CallerToken != nil {
+		result.AppendValidable(setting.CallerToken)
+
+		if len(setting.TokenCredentialId) > 0 {
+			result.AddError("actions.http.request.tokenCredentialId and actions.http.request.callerToken can't be used together")
+		}
+	}
+
 	return result
 }
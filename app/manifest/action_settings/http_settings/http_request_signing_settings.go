@@ -0,0 +1,207 @@
+package http_settings
+
+import (
+	"fmt"
+	"strings"
+	"wrench/app/manifest/types"
+	"wrench/app/manifest/validation"
+)
+
+type HttpRequestSigningType string
+
+const (
+	HttpRequestSigningTypeHmac                 HttpRequestSigningType = "hmac"
+	HttpRequestSigningTypeHttpMessageSignature HttpRequestSigningType = "httpMessageSignature"
+	HttpRequestSigningTypeAwsSigV4             HttpRequestSigningType = "awsSigV4"
+)
+
+type HttpMessageSignatureAlgorithm string
+
+const (
+	HttpMessageSignatureAlgorithmRsaPssSha512    HttpMessageSignatureAlgorithm = "rsa-pss-sha512"
+	HttpMessageSignatureAlgorithmRsaV15Sha256    HttpMessageSignatureAlgorithm = "rsa-v1_5-sha256"
+	HttpMessageSignatureAlgorithmEcdsaP256Sha256 HttpMessageSignatureAlgorithm = "ecdsa-p256-sha256"
+	HttpMessageSignatureAlgorithmEcdsaP384Sha384 HttpMessageSignatureAlgorithm = "ecdsa-p384-sha384"
+	HttpMessageSignatureAlgorithmEd25519         HttpMessageSignatureAlgorithm = "ed25519"
+	HttpMessageSignatureAlgorithmHmacSha256      HttpMessageSignatureAlgorithm = "hmac-sha256"
+)
+
+// Components accepted by the hmac profile, headers are referenced as "header:<name>".
+const (
+	HttpRequestSigningComponentMethod     = "method"
+	HttpRequestSigningComponentPath       = "path"
+	HttpRequestSigningComponentDate       = "date"
+	HttpRequestSigningComponentBodyDigest = "bodyDigest"
+	HttpRequestSigningComponentHeader     = "header:"
+)
+
+type HttpRequestSigningSettings struct {
+	Type                 HttpRequestSigningType          `yaml:"type"`
+	Hmac                 *HttpRequestHmacSigningSettings `yaml:"hmac"`
+	HttpMessageSignature *HttpMessageSignatureSettings   `yaml:"httpMessageSignature"`
+	AwsSigV4             *AwsSigV4SigningSettings        `yaml:"awsSigV4"`
+}
+
+// HttpRequestHmacSigningSettings signs the components joined by separator (default "\n"),
+// ex: components: [method, path, bodyDigest, date] signs "POST\n/orders?x=1\n<sha256 base64>\n<Date header>".
+type HttpRequestHmacSigningSettings struct {
+	Algorithm        types.HashAlg `yaml:"algorithm"`
+	Key              string        `yaml:"key"`
+	Components       []string      `yaml:"components"`
+	Separator        *string       `yaml:"separator"`
+	SignatureHeader  string        `yaml:"signatureHeader"`
+	SignaturePrefix  string        `yaml:"signaturePrefix"`
+	Base64           bool          `yaml:"base64"`
+	BodyDigestHeader string        `yaml:"bodyDigestHeader"`
+}
+
+// HttpMessageSignatureSettings follows RFC 9421, components are derived components (@method, @target-uri,
+// @authority, @path, @query) or lowercase header names. content-digest is created when it's a component.
+type HttpMessageSignatureSettings struct {
+	Label            string                        `yaml:"label"`
+	Algorithm        HttpMessageSignatureAlgorithm `yaml:"algorithm"`
+	KeyId            string                        `yaml:"keyId"`
+	Key              string                        `yaml:"key"`
+	Kid              string                        `yaml:"kid"`
+	Components       []string                      `yaml:"components"`
+	ExpiresInSeconds int                           `yaml:"expiresInSeconds"`
+	Tag              string                        `yaml:"tag"`
+}
+
+// AwsSigV4SigningSettings uses the default AWS credential chain when the access keys aren't informed.
+type AwsSigV4SigningSettings struct {
+	Region          string `yaml:"region"`
+	Service         string `yaml:"service"`
+	AccessKeyId     string `yaml:"accessKeyId"`
+	SecretAccessKey string `yaml:"secretAccessKey"`
+	SessionToken    string `yaml:"sessionToken"`
+}
+
+func (setting *HttpRequestHmacSigningSettings) GetSeparator() string {
+	if setting.Separator == nil {
+		return "\n"
+	}
+	return *setting.Separator
+}
+
+func (setting *HttpRequestHmacSigningSettings) GetSignatureHeader() string {
+	if len(setting.SignatureHeader) == 0 {
+		return "X-Signature"
+	}
+	return setting.SignatureHeader
+}
+
+func (setting *HttpMessageSignatureSettings) GetLabel() string {
+	if len(setting.Label) == 0 {
+		return "sig1"
+	}
+	return setting.Label
+}
+
+func (setting *HttpRequestSigningSettings) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	switch setting.Type {
+	case HttpRequestSigningTypeHmac:
+		if setting.Hmac == nil {
+			result.AddError("actions.http.request.signing.hmac is required when type is hmac")
+		} else {
+			result.Append(setting.Hmac.valid())
+		}
+	case HttpRequestSigningTypeHttpMessageSignature:
+		if setting.HttpMessageSignature == nil {
+			result.AddError("actions.http.request.signing.httpMessageSignature is required when type is httpMessageSignature")
+		} else {
+			result.Append(setting.HttpMessageSignature.valid())
+		}
+	case HttpRequestSigningTypeAwsSigV4:
+		if setting.AwsSigV4 == nil {
+			result.AddError("actions.http.request.signing.awsSigV4 is required when type is awsSigV4")
+		} else {
+			result.Append(setting.AwsSigV4.valid())
+		}
+	default:
+		result.AddError(fmt.Sprintf("actions.http.request.signing.type should contain valid value (%v, %v or %v)",
+			HttpRequestSigningTypeHmac, HttpRequestSigningTypeHttpMessageSignature, HttpRequestSigningTypeAwsSigV4))
+	}
+
+	return result
+}
+
+func (setting *HttpRequestHmacSigningSettings) valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if setting.Algorithm != types.HashAlgSHA256 &&
+		setting.Algorithm != types.HashAlgSHA512 &&
+		setting.Algorithm != types.HashAlgSHA1 {
+		result.AddError("actions.http.request.signing.hmac.algorithm should be SHA-256, SHA-512 or SHA-1")
+	}
+
+	if len(setting.Key) == 0 {
+		result.AddError("actions.http.request.signing.hmac.key is required")
+	}
+
+	if len(setting.Components) == 0 {
+		result.AddError("actions.http.request.signing.hmac.components is required")
+	}
+
+	for _, component := range setting.Components {
+		if component != HttpRequestSigningComponentMethod &&
+			component != HttpRequestSigningComponentPath &&
+			component != HttpRequestSigningComponentDate &&
+			component != HttpRequestSigningComponentBodyDigest &&
+			!strings.HasPrefix(component, HttpRequestSigningComponentHeader) {
+			result.AddError(fmt.Sprintf("actions.http.request.signing.hmac.components %v should be method, path, date, bodyDigest or header:<name>", component))
+		}
+	}
+
+	return result
+}
+
+func (setting *HttpMessageSignatureSettings) valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	switch setting.Algorithm {
+	case HttpMessageSignatureAlgorithmRsaPssSha512, HttpMessageSignatureAlgorithmRsaV15Sha256,
+		HttpMessageSignatureAlgorithmEcdsaP256Sha256, HttpMessageSignatureAlgorithmEcdsaP384Sha384, HttpMessageSignatureAlgorithmEd25519:
+		if len(setting.KeyId) == 0 {
+			result.AddError(fmt.Sprintf("actions.http.request.signing.httpMessageSignature.keyId is required when algorithm is %v", setting.Algorithm))
+		}
+	case HttpMessageSignatureAlgorithmHmacSha256:
+		if len(setting.Key) == 0 {
+			result.AddError("actions.http.request.signing.httpMessageSignature.key is required when algorithm is hmac-sha256")
+		}
+	default:
+		result.AddError(fmt.Sprintf("actions.http.request.signing.httpMessageSignature.algorithm should contain valid value (%v, %v, %v, %v, %v or %v)",
+			HttpMessageSignatureAlgorithmRsaPssSha512, HttpMessageSignatureAlgorithmRsaV15Sha256, HttpMessageSignatureAlgorithmEcdsaP256Sha256,
+			HttpMessageSignatureAlgorithmEcdsaP384Sha384, HttpMessageSignatureAlgorithmEd25519, HttpMessageSignatureAlgorithmHmacSha256))
+	}
+
+	if len(setting.Components) == 0 {
+		result.AddError("actions.http.request.signing.httpMessageSignature.components is required")
+	}
+
+	if setting.ExpiresInSeconds < 0 {
+		result.AddError("actions.http.request.signing.httpMessageSignature.expiresInSeconds can't be negative")
+	}
+
+	return result
+}
+
+func (setting *AwsSigV4SigningSettings) valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if len(setting.Region) == 0 {
+		result.AddError("actions.http.request.signing.awsSigV4.region is required")
+	}
+
+	if len(setting.Service) == 0 {
+		result.AddError("actions.http.request.signing.awsSigV4.service is required")
+	}
+
+	if len(setting.AccessKeyId) > 0 != (len(setting.SecretAccessKey) > 0) {
+		result.AddError("actions.http.request.signing.awsSigV4.accessKeyId and secretAccessKey should be informed together")
+	}
+
+	return result
+}
@@ -0,0 +1,66 @@
+package api_settings
+
+import (
+	"fmt"
+	"wrench/app/manifest/validation"
+)
+
+const defaultApiKeyHeaderName = "X-Api-Key"
+
+type ApiKeyAuthorizationSettings struct {
+	HeaderName     string               `yaml:"headerName"`
+	QueryParameter string               `yaml:"queryParameter"`
+	Keys           []*ApiKeySettings    `yaml:"keys"`
+	Redis          *ApiKeyRedisSettings `yaml:"redis"`
+}
+
+type ApiKeySettings struct {
+	Key           string   `yaml:"key"`
+	ConsumerId    string   `yaml:"consumerId"`
+	Roles         []string `yaml:"roles"`
+	RateLimitTier string   `yaml:"rateLimitTier"`
+}
+
+// ApiKeyRedisSettings looks up sha256 hex digests of the keys in SetKey, with optional
+// consumer json ({"consumerId", "roles", "rateLimitTier"}) in the hash "<setKey>:consumers".
+type ApiKeyRedisSettings struct {
+	RedisConnectionId string `yaml:"redisConnectionId"`
+	SetKey            string `yaml:"setKey"`
+}
+
+func (setting *ApiKeyAuthorizationSettings) GetHeaderName() string {
+	if len(setting.HeaderName) == 0 && len(setting.QueryParameter) == 0 {
+		return defaultApiKeyHeaderName
+	}
+	return setting.HeaderName
+}
+
+func (setting *ApiKeyAuthorizationSettings) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if len(setting.Keys) == 0 && setting.Redis == nil {
+		result.AddError("api.authorization.apiKey should set keys or redis")
+	}
+
+	for i, key := range setting.Keys {
+		if len(key.Key) == 0 {
+			result.AddError(fmt.Sprintf("api.authorization.apiKey.keys[%v].key is required", i))
+		}
+
+		if len(key.ConsumerId) == 0 {
+			result.AddError(fmt.Sprintf("api.authorization.apiKey.keys[%v].consumerId is required", i))
+		}
+	}
+
+	if setting.Redis != nil {
+		if len(setting.Redis.RedisConnectionId) == 0 {
+			result.AddError("api.authorization.apiKey.redis.redisConnectionId is required")
+		}
+
+		if len(setting.Redis.SetKey) == 0 {
+			result.AddError("api.authorization.apiKey.redis.setKey is required")
+		}
+	}
+
+	return result
+}
@@ -2,17 +2,69 @@ package api_settings
 
 import (
 	"errors"
+	"fmt"
 	"wrench/app/manifest/validation"
 )
 
 type ApiSettings struct {
-	Endpoints     []EndpointSettings     `yaml:"endpoints"`
-	Authorization *AuthorizationSettings `yaml:"authorization"`
-	Cors          *CorsSettings          `yaml:"cors"`
+	Endpoints      []EndpointSettings       `yaml:"endpoints"`
+	Authorization  *AuthorizationSettings   `yaml:"authorization"`
+	Authorizations []*AuthorizationSettings `yaml:"authorizations"`
+	Cors           *CorsSettings            `yaml:"cors"`
+	Tls            *TlsSettings             `yaml:"tls"`
+	ClientIp       *ClientIpSettings        `yaml:"clientIp"`
 }
 
 func (setting *ApiSettings) HasAuthorization() bool {
-	return setting.Authorization != nil
+	return setting.Authorization != nil || len(setting.Authorizations) > 0
+}
+
+func (setting *ApiSettings) GetAuthorizationById(id string) (*AuthorizationSettings, error) {
+	if setting.Authorization != nil && len(setting.Authorization.Id) > 0 && setting.Authorization.Id == id {
+		return setting.Authorization, nil
+	}
+
+	for _, authorization := range setting.Authorizations {
+		if authorization.Id == id {
+			return authorization, nil
+		}
+	}
+
+	return nil, fmt.Errorf("authorization %v not found", id)
+}
+
+// GetAllAuthorizations returns the default api.authorization together with the named schemes.
+func (setting *ApiSettings) GetAllAuthorizations() []*AuthorizationSettings {
+	var authorizations []*AuthorizationSettings
+
+	if setting.Authorization != nil {
+		authorizations = append(authorizations, setting.Authorization)
+	}
+
+	return append(authorizations, setting.Authorizations...)
+}
+
+// GetEndpointAuthorizations returns the schemes picked by the endpoint, falling back to api.authorization.
+func (setting *ApiSettings) GetEndpointAuthorizations(endpoint *EndpointSettings) []*AuthorizationSettings {
+	if endpoint.EnableAnonymous {
+		return nil
+	}
+
+	if endpoint.Authorization == nil {
+		if setting.Authorization != nil {
+			return []*AuthorizationSettings{setting.Authorization}
+		}
+		return nil
+	}
+
+	var authorizations []*AuthorizationSettings
+	for _, scheme := range endpoint.Authorization.Schemes {
+		if authorization, err := setting.GetAuthorizationById(scheme); err == nil {
+			authorizations = append(authorizations, authorization)
+		}
+	}
+
+	return authorizations
 }
 
 func (setting *ApiSettings) GetEndpointByRoute(route string) (*EndpointSettings, error) {
@@ -39,6 +91,8 @@ func (settings *ApiSettings) Merge(toMerge *ApiSettings) error {
 		}
 	}
 
+	settings.Authorizations = append(settings.Authorizations, toMerge.Authorizations...)
+
 	if len(toMerge.Endpoints) > 0 {
 		if len(settings.Endpoints) == 0 {
 			settings.Endpoints = toMerge.Endpoints
@@ -47,6 +101,18 @@ func (settings *ApiSettings) Merge(toMerge *ApiSettings) error {
 		}
 	}
 
+	if settings.Tls != nil && toMerge.Tls != nil {
+		return errors.New("should configure only once api.tls")
+	} else if toMerge.Tls != nil {
+		settings.Tls = toMerge.Tls
+	}
+
+	if settings.ClientIp != nil && toMerge.ClientIp != nil {
+		return errors.New("should configure only once api.clientIp")
+	} else if toMerge.ClientIp != nil {
+		settings.ClientIp = toMerge.ClientIp
+	}
+
 	if settings.Cors == nil && toMerge.Cors != nil {
 		settings.Cors = &CorsSettings{}
 	}
@@ -74,9 +140,25 @@ func (setting *ApiSettings) Valid() validation.ValidateResult {
 		result.AppendValidable(setting.Authorization)
 	}
 
+	for _, authorization := range setting.Authorizations {
+		if len(authorization.Id) == 0 {
+			result.AddError("api.authorizations.id is required")
+		}
+
+		result.AppendValidable(authorization)
+	}
+
 	if setting.Cors != nil {
 		result.AppendValidable(setting.Cors)
 	}
 
+	if setting.Tls != nil {
+		result.AppendValidable(setting.Tls)
+	}
+
+	if setting.ClientIp != nil {
+		result.AppendValidable(setting.ClientIp)
+	}
+
 	return result
 }
@@ -1,37 +1,90 @@
 package api_settings
 
 import (
+	"fmt"
+	"strings"
 	"wrench/app/manifest/types"
 	"wrench/app/manifest/validation"
 )
 
 type AuthorizationSettings struct {
-	Type              AuthorizationType `yaml:"type"`
-	JwksUrl           string            `yaml:"jwksUrl"`
-	Algorithm         types.HashAlg     `yaml:"algorithm"`
-	Kid               string            `yaml:"kid"`
-	Key               string            `yaml:"key"`
-	SignatureRef      string            `yaml:"signatureRef"`
-	ConcatenateFields []string          `yaml:"concatenateFields"`
+	Id                string                       `yaml:"id"`
+	Type              AuthorizationType            `yaml:"type"`
+	JwksUrl           string                       `yaml:"jwksUrl"`
+	Algorithm         types.HashAlg                `yaml:"algorithm"`
+	Kid               string                       `yaml:"kid"`
+	Key               string                       `yaml:"key"`
+	SignatureRef      string                       `yaml:"signatureRef"`
+	ConcatenateFields []string                     `yaml:"concatenateFields"`
+	ApiKey            *ApiKeyAuthorizationSettings `yaml:"apiKey"`
+	Introspection     *IntrospectionSettings       `yaml:"introspection"`
+	Hmac              *HmacSettings                `yaml:"hmac"`
+
+	Issuers                      []string                `yaml:"issuers"`
+	Audiences                    []string                `yaml:"audiences"`
+	Algorithms                   []string                `yaml:"algorithms"`
+	ClockSkewInSeconds           int                     `yaml:"clockSkewInSeconds"`
+	RequiredClaims               []string                `yaml:"requiredClaims"`
+	PublicKeys                   []*JwtPublicKeySettings `yaml:"publicKeys"`
+	JwksRefreshIntervalInSeconds int                     `yaml:"jwksRefreshIntervalInSeconds"`
+	JwksRefreshUnknownKid        bool                    `yaml:"jwksRefreshUnknownKid"`
+	ClaimPaths                   *ClaimPathsSettings     `yaml:"claimPaths"`
 }
 
 type AuthorizationType string
 
 const (
-	JWKSAuthorizationType AuthorizationType = "jwks"
-	HMACAuthorizationType AuthorizationType = "hmac"
+	JWKSAuthorizationType          AuthorizationType = "jwks"
+	HMACAuthorizationType          AuthorizationType = "hmac"
+	ApiKeyAuthorizationType        AuthorizationType = "apiKey"
+	IntrospectionAuthorizationType AuthorizationType = "introspection"
 )
 
+func (setting *AuthorizationSettings) GetId() string {
+	return setting.Id
+}
+
+// GetAllowedAlgorithms falls back to the single algorithm when the list isn't informed.
+func (setting *AuthorizationSettings) GetAllowedAlgorithms() []string {
+	if len(setting.Algorithms) > 0 {
+		return setting.Algorithms
+	}
+
+	if len(setting.Algorithm) > 0 {
+		return []string{string(setting.Algorithm)}
+	}
+
+	return nil
+}
+
 func (setting AuthorizationSettings) Valid() validation.ValidateResult {
 	var result validation.ValidateResult
 
 	if setting.Type == JWKSAuthorizationType {
-		if setting.JwksUrl == "" {
-			result.AddError("api.authorization.jwksUrl is required when type is jwks")
+		if setting.JwksUrl == "" && len(setting.PublicKeys) == 0 {
+			result.AddError("api.authorization.jwksUrl or api.authorization.publicKeys is required when type is jwks")
 		}
 
-		if setting.Algorithm == "" {
-			result.AddError("api.authorization.algorithm is required when type is jwks")
+		if setting.Algorithm == "" && len(setting.Algorithms) == 0 {
+			result.AddError("api.authorization.algorithm or api.authorization.algorithms is required when type is jwks")
+		}
+
+		for _, algorithm := range setting.GetAllowedAlgorithms() {
+			if strings.HasPrefix(strings.ToUpper(algorithm), "HS") || strings.EqualFold(algorithm, "none") {
+				result.AddError(fmt.Sprintf("api.authorization.algorithms %v is not allowed for jwks", algorithm))
+			}
+		}
+
+		if setting.ClockSkewInSeconds < 0 {
+			result.AddError("api.authorization.clockSkewInSeconds can't be negative")
+		}
+
+		if setting.JwksRefreshIntervalInSeconds < 0 {
+			result.AddError("api.authorization.jwksRefreshIntervalInSeconds can't be negative")
+		}
+
+		for _, publicKey := range setting.PublicKeys {
+			result.AppendValidable(publicKey)
 		}
 	}
 
@@ -51,11 +104,33 @@ func (setting AuthorizationSettings) Valid() validation.ValidateResult {
 		if len(setting.ConcatenateFields) == 0 {
 			result.AddError("api.authorization.concatenateFields is required when type is hmac")
 		}
+
+		if setting.Hmac != nil {
+			result.AppendValidable(setting.Hmac)
+		}
+	}
+
+	if setting.Type == ApiKeyAuthorizationType {
+		if setting.ApiKey == nil {
+			result.AddError("api.authorization.apiKey is required when type is apiKey")
+		} else {
+			result.AppendValidable(setting.ApiKey)
+		}
+	}
+
+	if setting.Type == IntrospectionAuthorizationType {
+		if setting.Introspection == nil {
+			result.AddError("api.authorization.introspection is required when type is introspection")
+		} else {
+			result.AppendValidable(setting.Introspection)
+		}
 	}
 
 	if setting.Type != JWKSAuthorizationType &&
-		setting.Type != HMACAuthorizationType {
-		result.AddError("api.authorization.type should be a valid type (jwks or hmac)")
+		setting.Type != HMACAuthorizationType &&
+		setting.Type != ApiKeyAuthorizationType &&
+		setting.Type != IntrospectionAuthorizationType {
+		result.AddError("api.authorization.type should be a valid type (jwks, hmac, apiKey or introspection)")
 	}
 
 	return result
@@ -0,0 +1,22 @@
+package api_settings
+
+// ClaimPathsSettings uses json_map paths, ex: realm_access.roles or resource_access.my-client.roles.
+// When several paths are informed their values are merged.
+type ClaimPathsSettings struct {
+	Roles  []string `yaml:"roles"`
+	Scopes []string `yaml:"scopes"`
+}
+
+func (setting *ClaimPathsSettings) GetRoles() []string {
+	if setting == nil || len(setting.Roles) == 0 {
+		return []string{"roles"}
+	}
+	return setting.Roles
+}
+
+func (setting *ClaimPathsSettings) GetScopes() []string {
+	if setting == nil || len(setting.Scopes) == 0 {
+		return []string{"scope"}
+	}
+	return setting.Scopes
+}
@@ -0,0 +1,48 @@
+package api_settings
+
+import (
+	"fmt"
+	"regexp"
+	"wrench/app/manifest/validation"
+)
+
+// ClaimRuleSettings checks the claim found in Path with one operator, Contains matches a substring.
+// Array claims match when any item satisfies the operator.
+type ClaimRuleSettings struct {
+	Path     string   `yaml:"path"`
+	Equals   string   `yaml:"equals"`
+	AnyOf    []string `yaml:"anyOf"`
+	Regex    string   `yaml:"regex"`
+	Contains string   `yaml:"contains"`
+}
+
+func (setting *ClaimRuleSettings) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if len(setting.Path) == 0 {
+		result.AddError("api.endpoints.claimRules.path is required")
+	}
+
+	operators := 0
+	if len(setting.Equals) > 0 {
+		operators++
+	}
+	if len(setting.AnyOf) > 0 {
+		operators++
+	}
+	if len(setting.Regex) > 0 {
+		operators++
+		if _, err := regexp.Compile(setting.Regex); err != nil {
+			result.AddError(fmt.Sprintf("api.endpoints.claimRules[%v].regex is invalid: %v", setting.Path, err))
+		}
+	}
+	if len(setting.Contains) > 0 {
+		operators++
+	}
+
+	if operators != 1 {
+		result.AddError(fmt.Sprintf("api.endpoints.claimRules[%v] should set one of equals, anyOf, regex or contains", setting.Path))
+	}
+
+	return result
+}
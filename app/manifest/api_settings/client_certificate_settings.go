@@ -0,0 +1,9 @@
+package api_settings
+
+// ClientCertificateSettings requires a verified client certificate on the endpoint. When informed,
+// the certificate subject should be one of subjects and one of its SANs should be in sans,
+// ex: subjects: ["CN=partner-a,O=Partner A,C=BR"] sans: ["partner-a.example.com"].
+type ClientCertificateSettings struct {
+	Subjects []string `yaml:"subjects"`
+	Sans     []string `yaml:"sans"`
+}
@@ -0,0 +1,85 @@
+package api_settings
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+	"wrench/app/manifest/validation"
+)
+
+const defaultForwardedHeader = "X-Forwarded-For"
+
+// ClientIpSettings the forwardedHeader is read only when the request comes from one of the trustedProxies
+// (ips or cidrs), otherwise the remote address is the client ip.
+type ClientIpSettings struct {
+	TrustedProxies  []string `yaml:"trustedProxies"`
+	ForwardedHeader string   `yaml:"forwardedHeader"`
+
+	trustedProxiesOnce     sync.Once
+	trustedProxiesPrefixes []netip.Prefix
+}
+
+func (setting *ClientIpSettings) GetForwardedHeader() string {
+	if setting == nil || len(setting.ForwardedHeader) == 0 {
+		return defaultForwardedHeader
+	}
+	return setting.ForwardedHeader
+}
+
+func (setting *ClientIpSettings) IsForwardedFor() bool {
+	return strings.EqualFold(setting.GetForwardedHeader(), defaultForwardedHeader)
+}
+
+func (setting *ClientIpSettings) IsTrustedProxy(ip string) bool {
+	if setting == nil || len(setting.TrustedProxies) == 0 {
+		return false
+	}
+
+	address, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	address = address.Unmap()
+
+	setting.trustedProxiesOnce.Do(func() {
+		for _, trustedProxy := range setting.TrustedProxies {
+			if prefix, err := parseTrustedProxy(trustedProxy); err == nil {
+				setting.trustedProxiesPrefixes = append(setting.trustedProxiesPrefixes, prefix)
+			}
+		}
+	})
+
+	for _, prefix := range setting.trustedProxiesPrefixes {
+		if prefix.Contains(address) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTrustedProxy(trustedProxy string) (netip.Prefix, error) {
+	if strings.Contains(trustedProxy, "/") {
+		prefix, err := netip.ParsePrefix(trustedProxy)
+		return prefix.Masked(), err
+	}
+
+	address, err := netip.ParseAddr(trustedProxy)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	address = address.Unmap()
+	return netip.PrefixFrom(address, address.BitLen()), nil
+}
+
+func (setting *ClientIpSettings) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	for _, trustedProxy := range setting.TrustedProxies {
+		if _, err := parseTrustedProxy(trustedProxy); err != nil {
+			result.AddError(fmt.Sprintf("api.clientIp.trustedProxies %v should be an ip or cidr", trustedProxy))
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,41 @@
+package api_settings
+
+import (
+	"fmt"
+	"wrench/app/manifest/validation"
+)
+
+type AuthorizationMode string
+
+const (
+	AuthorizationModeAnyOf AuthorizationMode = "anyOf"
+	AuthorizationModeAllOf AuthorizationMode = "allOf"
+)
+
+type EndpointAuthorizationSettings struct {
+	Schemes []string          `yaml:"schemes"`
+	Mode    AuthorizationMode `yaml:"mode"`
+}
+
+func (setting *EndpointAuthorizationSettings) GetMode() AuthorizationMode {
+	if setting == nil || len(setting.Mode) == 0 {
+		return AuthorizationModeAnyOf
+	}
+	return setting.Mode
+}
+
+func (setting *EndpointAuthorizationSettings) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if len(setting.Schemes) == 0 {
+		result.AddError("api.endpoints.authorization.schemes is required")
+	}
+
+	if setting.Mode != "" &&
+		setting.Mode != AuthorizationModeAnyOf &&
+		setting.Mode != AuthorizationModeAllOf {
+		result.AddError(fmt.Sprintf("api.endpoints.authorization.mode %v should be anyOf or allOf", setting.Mode))
+	}
+
+	return result
+}
@@ -7,23 +7,37 @@ import (
 )
 
 type EndpointSettings struct {
-	Route           string           `yaml:"route"`
-	Method          types.HttpMethod `yaml:"method"`
-	ActionID        string           `yaml:"actionId"`
-	FlowActionID    []string         `yaml:"flowActionId"`
-	EnableAnonymous bool             `yaml:"enableAnonymous"`
-	Roles           []string         `yaml:"roles"`
-	Scopes          []string         `yaml:"scopes"`
-	Claims          []string         `yaml:"claims"`
-	IsProxy         bool             `yaml:"isProxy"`
-	IdempId         string           `yaml:"idempId"`
-	RateLimitId     string           `yaml:"rateLimitId"`
+	Route             string                         `yaml:"route"`
+	Method            types.HttpMethod               `yaml:"method"`
+	ActionID          string                         `yaml:"actionId"`
+	FlowActionID      []string                       `yaml:"flowActionId"`
+	EnableAnonymous   bool                           `yaml:"enableAnonymous"`
+	Authorization     *EndpointAuthorizationSettings `yaml:"authorization"`
+	Roles             []string                       `yaml:"roles"`
+	Scopes            []string                       `yaml:"scopes"`
+	Claims            []string                       `yaml:"claims"`
+	ClaimRules        []*ClaimRuleSettings           `yaml:"claimRules"`
+	IsProxy           bool                           `yaml:"isProxy"`
+	IdempId           string                         `yaml:"idempId"`
+	RateLimitId       string                         `yaml:"rateLimitId"`
+	RateLimitIds      []string                       `yaml:"rateLimitIds"`
+	CacheId           string                         `yaml:"cacheId"`
+	Form              *FormSettings                  `yaml:"form"`
+	ClientCertificate *ClientCertificateSettings     `yaml:"clientCertificate"`
 }
 
 func (setting EndpointSettings) ShouldConfigureAuthorization(apiHasAuthorization bool) bool {
 	return apiHasAuthorization && !setting.EnableAnonymous
 }
 
+func (setting EndpointSettings) GetRateLimitIds() []string {
+	if len(setting.RateLimitId) == 0 {
+		return setting.RateLimitIds
+	}
+
+	return append([]string{setting.RateLimitId}, setting.RateLimitIds...)
+}
+
 func (setting EndpointSettings) Valid() validation.ValidateResult {
 	var result validation.ValidateResult
 
@@ -65,5 +79,18 @@ func (setting EndpointSettings) Valid() validation.ValidateResult {
 			result.AddError(msg)
 		}
 	}
+
+	if setting.Authorization != nil {
+		result.AppendValidable(setting.Authorization)
+	}
+
+	for _, claimRule := range setting.ClaimRules {
+		result.AppendValidable(claimRule)
+	}
+
+	if setting.Form != nil {
+		result.AppendValidable(setting.Form)
+	}
+
 	return result
 }
@@ -0,0 +1,50 @@
+package api_settings
+
+import (
+	"fmt"
+	"wrench/app/manifest/validation"
+)
+
+type FormFileEncoding string
+
+const (
+	FormFileEncodingBase64      FormFileEncoding = "base64"
+	FormFileEncodingPassthrough FormFileEncoding = "passthrough"
+)
+
+type FormSettings struct {
+	FileEncoding  FormFileEncoding `yaml:"fileEncoding"`
+	MaxMemoryInMB int64            `yaml:"maxMemoryInMB"`
+}
+
+func (setting *FormSettings) GetFileEncoding() FormFileEncoding {
+	if setting == nil || len(setting.FileEncoding) == 0 {
+		return FormFileEncodingBase64
+	}
+
+	return setting.FileEncoding
+}
+
+func (setting *FormSettings) GetMaxMemory() int64 {
+	if setting == nil || setting.MaxMemoryInMB <= 0 {
+		return 32 << 20
+	}
+
+	return setting.MaxMemoryInMB << 20
+}
+
+func (setting *FormSettings) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if len(setting.FileEncoding) > 0 &&
+		setting.FileEncoding != FormFileEncodingBase64 &&
+		setting.FileEncoding != FormFileEncodingPassthrough {
+		result.AddError(fmt.Sprintf("api.endpoints.form.fileEncoding should contain valid value (%v or %v)", FormFileEncodingBase64, FormFileEncodingPassthrough))
+	}
+
+	if setting.MaxMemoryInMB < 0 {
+		result.AddError("api.endpoints.form.maxMemoryInMB should be greater than 0")
+	}
+
+	return result
+}
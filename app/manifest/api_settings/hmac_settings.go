@@ -0,0 +1,112 @@
+package api_settings
+
+import (
+	"fmt"
+	"time"
+	"wrench/app/manifest/types"
+	"wrench/app/manifest/validation"
+)
+
+type HmacSignatureFormat string
+
+const (
+	HmacSignatureFormatHex    HmacSignatureFormat = "hex"
+	HmacSignatureFormatBase64 HmacSignatureFormat = "base64"
+	// HmacSignatureFormatKeyValue parses signatures like "t=1700000000,v1=5257a8...,v1=9d2e1c..."
+	HmacSignatureFormatKeyValue HmacSignatureFormat = "keyValue"
+)
+
+const defaultHmacToleranceInSeconds = 300
+
+// HmacSettings hardens the hmac authorization against replays. When signedTimestamp is set the
+// signed payload is "<timestamp>.<concatenateFields>", the same scheme used by Stripe webhooks.
+type HmacSettings struct {
+	SignatureFormat    HmacSignatureFormat `yaml:"signatureFormat"`
+	TimestampKey       string              `yaml:"timestampKey"`
+	SignatureKey       string              `yaml:"signatureKey"`
+	TimestampRef       string              `yaml:"timestampRef"`
+	ToleranceInSeconds int                 `yaml:"toleranceInSeconds"`
+	SignedTimestamp    bool                `yaml:"signedTimestamp"`
+	NonceRef           string              `yaml:"nonceRef"`
+	NonceTtlInSeconds  int                 `yaml:"nonceTtlInSeconds"`
+	RedisConnectionId  string              `yaml:"redisConnectionId"`
+	MaxEntries         int                 `yaml:"maxEntries"`
+}
+
+func (setting *HmacSettings) GetSignatureFormat() HmacSignatureFormat {
+	if setting == nil || len(setting.SignatureFormat) == 0 {
+		return HmacSignatureFormatHex
+	}
+	return setting.SignatureFormat
+}
+
+func (setting *HmacSettings) GetTimestampKey() string {
+	if len(setting.TimestampKey) == 0 {
+		return "t"
+	}
+	return setting.TimestampKey
+}
+
+func (setting *HmacSettings) GetSignatureKey() string {
+	if len(setting.SignatureKey) == 0 {
+		return "v1"
+	}
+	return setting.SignatureKey
+}
+
+// HasTimestamp is true when the timestamp comes from a header or from the key value signature.
+func (setting *HmacSettings) HasTimestamp() bool {
+	return setting != nil &&
+		(len(setting.TimestampRef) > 0 || setting.GetSignatureFormat() == HmacSignatureFormatKeyValue)
+}
+
+func (setting *HmacSettings) GetTolerance() time.Duration {
+	if setting.ToleranceInSeconds == 0 {
+		return defaultHmacToleranceInSeconds * time.Second
+	}
+	return time.Duration(setting.ToleranceInSeconds) * time.Second
+}
+
+// GetNonceTtl keeps the nonces at least while a timestamp is still accepted (past and future tolerance).
+func (setting *HmacSettings) GetNonceTtl() time.Duration {
+	if setting.NonceTtlInSeconds > 0 {
+		return time.Duration(setting.NonceTtlInSeconds) * time.Second
+	}
+	return 2 * setting.GetTolerance()
+}
+
+func (setting *HmacSettings) GetNonceBackend() types.BackendType {
+	if len(setting.RedisConnectionId) == 0 {
+		return types.BackendTypeMemory
+	}
+	return types.BackendTypeRedis
+}
+
+func (setting *HmacSettings) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	format := setting.GetSignatureFormat()
+	if format != HmacSignatureFormatHex &&
+		format != HmacSignatureFormatBase64 &&
+		format != HmacSignatureFormatKeyValue {
+		result.AddError(fmt.Sprintf("api.authorization.hmac.signatureFormat %v should be hex, base64 or keyValue", setting.SignatureFormat))
+	}
+
+	if setting.ToleranceInSeconds < 0 {
+		result.AddError("api.authorization.hmac.toleranceInSeconds can't be negative")
+	}
+
+	if setting.NonceTtlInSeconds < 0 {
+		result.AddError("api.authorization.hmac.nonceTtlInSeconds can't be negative")
+	}
+
+	if setting.MaxEntries < 0 {
+		result.AddError("api.authorization.hmac.maxEntries can't be negative")
+	}
+
+	if setting.SignedTimestamp && !setting.HasTimestamp() {
+		result.AddError("api.authorization.hmac.signedTimestamp requires timestampRef or signatureFormat keyValue")
+	}
+
+	return result
+}
@@ -0,0 +1,28 @@
+package api_settings
+
+import "wrench/app/manifest/validation"
+
+type IntrospectionSettings struct {
+	Endpoint          string `yaml:"endpoint"`
+	TokenCredentialId string `yaml:"tokenCredentialId"`
+	TokenTypeHint     string `yaml:"tokenTypeHint"`
+	MaxEntries        int    `yaml:"maxEntries"`
+}
+
+func (setting *IntrospectionSettings) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if len(setting.Endpoint) == 0 {
+		result.AddError("api.authorization.introspection.endpoint is required")
+	}
+
+	if len(setting.TokenCredentialId) == 0 {
+		result.AddError("api.authorization.introspection.tokenCredentialId is required")
+	}
+
+	if setting.MaxEntries < 0 {
+		result.AddError("api.authorization.introspection.maxEntries can't be negative")
+	}
+
+	return result
+}
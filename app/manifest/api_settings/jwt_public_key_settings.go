@@ -0,0 +1,36 @@
+package api_settings
+
+import (
+	"fmt"
+	"wrench/app/manifest/validation"
+)
+
+// JwtPublicKeySettings is a static verification key, as PEM (public key or certificate),
+// a JWK/JWKS json or a file holding any of them.
+type JwtPublicKeySettings struct {
+	Kid  string `yaml:"kid"`
+	Pem  string `yaml:"pem"`
+	Jwk  string `yaml:"jwk"`
+	File string `yaml:"file"`
+}
+
+func (setting *JwtPublicKeySettings) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	informed := 0
+	for _, value := range []string{setting.Pem, setting.Jwk, setting.File} {
+		if len(value) > 0 {
+			informed++
+		}
+	}
+
+	if informed != 1 {
+		result.AddError(fmt.Sprintf("api.authorization.publicKeys[%v] should set one of pem, jwk or file", setting.Kid))
+	}
+
+	if len(setting.Pem) > 0 && len(setting.Kid) == 0 {
+		result.AddError("api.authorization.publicKeys.kid is required when pem is informed")
+	}
+
+	return result
+}
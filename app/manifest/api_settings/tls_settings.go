@@ -0,0 +1,55 @@
+package api_settings
+
+import (
+	"fmt"
+	"wrench/app/manifest/validation"
+)
+
+type TlsClientAuth string
+
+const (
+	TlsClientAuthNone     TlsClientAuth = "none"
+	TlsClientAuthOptional TlsClientAuth = "optional"
+	TlsClientAuthRequired TlsClientAuth = "required"
+)
+
+// TlsSettings makes the gateway terminate TLS. With clientAuth optional only the endpoints
+// with clientCertificate configured require a certificate, with required every connection does.
+type TlsSettings struct {
+	CertFile     string        `yaml:"certFile"`
+	KeyFile      string        `yaml:"keyFile"`
+	ClientCaFile string        `yaml:"clientCaFile"`
+	ClientAuth   TlsClientAuth `yaml:"clientAuth"`
+}
+
+func (setting *TlsSettings) GetClientAuth() TlsClientAuth {
+	if setting == nil || len(setting.ClientAuth) == 0 {
+		return TlsClientAuthNone
+	}
+	return setting.ClientAuth
+}
+
+func (setting *TlsSettings) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if len(setting.CertFile) == 0 {
+		result.AddError("api.tls.certFile is required")
+	}
+
+	if len(setting.KeyFile) == 0 {
+		result.AddError("api.tls.keyFile is required")
+	}
+
+	clientAuth := setting.GetClientAuth()
+	if clientAuth != TlsClientAuthNone &&
+		clientAuth != TlsClientAuthOptional &&
+		clientAuth != TlsClientAuthRequired {
+		result.AddError(fmt.Sprintf("api.tls.clientAuth %v should be none, optional or required", setting.ClientAuth))
+	}
+
+	if clientAuth != TlsClientAuthNone && len(setting.ClientCaFile) == 0 {
+		result.AddError("api.tls.clientCaFile is required when api.tls.clientAuth is optional or required")
+	}
+
+	return result
+}
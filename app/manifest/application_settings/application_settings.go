@@ -6,6 +6,7 @@ import (
 	"log"
 	"wrench/app/manifest/action_settings"
 	"wrench/app/manifest/api_settings"
+	"wrench/app/manifest/cache_settings"
 	"wrench/app/manifest/key_settings"
 	"wrench/app/manifest/rate_limit_settings"
 
@@ -31,6 +32,7 @@ type ApplicationSettings struct {
 	Idemps           []*idemp_settings.IdempSettings          `yaml:"idemps"`
 	RateLimits       []*rate_limit_settings.RateLimitSettings `yaml:"rateLimits"`
 	Keys             []*key_settings.KeySettings              `yaml:"keys"`
+	Caches           []*cache_settings.CacheSettings          `yaml:"caches"`
 }
 
 func (settings *ApplicationSettings) GetActionById(actionId string) (*action_settings.ActionSettings, error) {
@@ -104,6 +106,12 @@ func (settings *ApplicationSettings) Valid() validation.ValidateResult {
 		}
 	}
 
+	if settings.Caches != nil {
+		for _, validable := range settings.Caches {
+			result.AppendValidable(validable)
+		}
+	}
+
 	return result
 }
 
@@ -184,6 +192,14 @@ func (settings *ApplicationSettings) Merge(toMerge *ApplicationSettings) error {
 		}
 	}
 
+	if len(toMerge.Caches) > 0 {
+		if len(settings.Caches) == 0 {
+			settings.Caches = toMerge.Caches
+		} else {
+			settings.Caches = append(settings.Caches, toMerge.Caches...)
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,86 @@
+package cache_settings
+
+import (
+	"fmt"
+	"wrench/app/manifest/types"
+	"wrench/app/manifest/validation"
+)
+
+type CacheSettings struct {
+	Id                string            `yaml:"id"`
+	Backend           types.BackendType `yaml:"backend"`
+	RedisConnectionId string            `yaml:"redisConnectionId"`
+	MaxEntries        int               `yaml:"maxEntries"`
+	Keys              []string          `yaml:"keys"`
+	TtlInSeconds      int               `yaml:"ttlInSeconds"`
+	StaleInSeconds    int               `yaml:"staleInSeconds"`
+	StatusCodes       []int             `yaml:"statusCodes"`
+}
+
+func (setting *CacheSettings) GetId() string {
+	return setting.Id
+}
+
+func (setting *CacheSettings) GetBackend() types.BackendType {
+	if len(setting.Backend) == 0 {
+		return types.BackendTypeRedis
+	}
+	return setting.Backend
+}
+
+func (setting *CacheSettings) IsStatusCodeCacheable(statusCode int) bool {
+	if len(setting.StatusCodes) == 0 {
+		return statusCode == 200
+	}
+
+	for _, code := range setting.StatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (setting *CacheSettings) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if len(setting.Id) == 0 {
+		result.AddError("caches.id is required")
+	}
+
+	if setting.GetBackend() == types.BackendTypeRedis &&
+		len(setting.RedisConnectionId) == 0 {
+		result.AddError(fmt.Sprintf("caches[%v].redisConnectionId is required", setting.Id))
+	}
+
+	if setting.Backend != "" &&
+		setting.Backend != types.BackendTypeRedis &&
+		setting.Backend != types.BackendTypeMemory {
+		result.AddError(fmt.Sprintf("caches[%v].backend should be redis or memory", setting.Id))
+	}
+
+	if setting.MaxEntries < 0 {
+		result.AddError(fmt.Sprintf("caches[%v].maxEntries can't be negative", setting.Id))
+	}
+
+	if len(setting.Keys) == 0 {
+		result.AddError(fmt.Sprintf("caches[%v].keys is required", setting.Id))
+	}
+
+	if setting.TtlInSeconds <= 0 {
+		result.AddError(fmt.Sprintf("caches[%v].ttlInSeconds should be greater than 0", setting.Id))
+	}
+
+	if setting.StaleInSeconds < 0 {
+		result.AddError(fmt.Sprintf("caches[%v].staleInSeconds can't be negative", setting.Id))
+	}
+
+	for _, statusCode := range setting.StatusCodes {
+		if statusCode < 100 || statusCode > 599 {
+			result.AddError(fmt.Sprintf("caches[%v].statusCodes %v is not a valid http status code", setting.Id, statusCode))
+		}
+	}
+
+	return result
+}
@@ -7,7 +7,7 @@ import (
 	"wrench/app/manifest/validation"
 )
 
-var funcValids = []string{"rename", "new", "remove", "duplicate", "parse", "format", "math"}
+var funcValids = []string{"rename", "new", "remove", "duplicate", "parse", "format", "math", "encrypt", "decrypt"}
 
 type ContractMapSetting struct {
 	Id        string          `yaml:"id"`
@@ -19,6 +19,8 @@ type ContractMapSetting struct {
 	Parse     *ParseSettings  `yaml:"parse"`
 	Format    *FormatSettings `yaml:"format"`
 	Math      *MathSettings   `yaml:"math"`
+	Encrypt   *CryptoSettings `yaml:"encrypt"`
+	Decrypt   *CryptoSettings `yaml:"decrypt"`
 }
 
 func (setting ContractMapSetting) Valid() validation.ValidateResult {
@@ -90,6 +92,16 @@ func (setting ContractMapSetting) Valid() validation.ValidateResult {
 		result.AppendValidable(setting.Math)
 	}
 
+	if setting.Encrypt != nil {
+		totalMapConfigured++
+		result.AppendValidable(setting.Encrypt)
+	}
+
+	if setting.Decrypt != nil {
+		totalMapConfigured++
+		result.AppendValidable(setting.Decrypt)
+	}
+
 	if len(setting.Sequence) > 0 {
 
 		if totalMapConfigured != len(setting.Sequence) {
@@ -113,6 +125,10 @@ func (setting ContractMapSetting) Valid() validation.ValidateResult {
 				result.AddError("contract.maps.sequence format not configured")
 			} else if s == "math" && setting.Math == nil {
 				result.AddError("contract.maps.sequence math not configured")
+			} else if s == "encrypt" && setting.Encrypt == nil {
+				result.AddError("contract.maps.sequence encrypt not configured")
+			} else if s == "decrypt" && setting.Decrypt == nil {
+				result.AddError("contract.maps.sequence decrypt not configured")
 			}
 		}
 	}
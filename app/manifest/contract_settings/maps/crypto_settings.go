@@ -0,0 +1,47 @@
+package maps
+
+import (
+	"fmt"
+	"slices"
+	"wrench/app/manifest/types"
+	"wrench/app/manifest/validation"
+)
+
+// CryptoSettings encrypts or decrypts the fields, the values are encrypted as JSON so they're
+// decrypted with the same type. A256GCM binds the field path and the values of ContextFields
+// (e.g. the record id) as additional data, a ciphertext moved to another field or record fails.
+type CryptoSettings struct {
+	KeyId         string              `yaml:"keyId"`
+	Algorithm     types.EncryptionAlg `yaml:"algorithm"`
+	Kid           string              `yaml:"kid"`
+	Fields        []string            `yaml:"fields"`
+	ContextFields []string            `yaml:"contextFields"`
+}
+
+func (setting CryptoSettings) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if len(setting.KeyId) == 0 {
+		result.AddError("contract.maps.encrypt/decrypt.keyId is required")
+	}
+
+	if !slices.Contains(types.SupportedEncryptionAlgorithms, setting.Algorithm) {
+		result.AddError(fmt.Sprintf("contract.maps.encrypt/decrypt.algorithm should be one of %v", types.SupportedEncryptionAlgorithms))
+	}
+
+	if len(setting.Fields) == 0 {
+		result.AddError("contract.maps.encrypt/decrypt.fields is required")
+	}
+
+	if len(setting.ContextFields) > 0 && setting.Algorithm != types.EncryptionAlgA256GCM {
+		result.AddError("contract.maps.encrypt/decrypt.contextFields is only supported by A256GCM")
+	}
+
+	for _, contextField := range setting.ContextFields {
+		if slices.Contains(setting.Fields, contextField) {
+			result.AddError(fmt.Sprintf("contract.maps.encrypt/decrypt.contextFields %v can't be encrypted", contextField))
+		}
+	}
+
+	return result
+}
@@ -1,18 +1,65 @@
 package idemp_settings
 
-import "wrench/app/manifest/validation"
+import (
+	"fmt"
+	"time"
+	"wrench/app/manifest/types"
+	"wrench/app/manifest/validation"
+)
 
 type IdempSettings struct {
-	Id                string `yaml:"id"`
-	RedisConnectionId string `yaml:"redisConnectionId"`
-	Key               string `yaml:"key"`
-	TtlInSeconds      int    `yaml:"ttlInSeconds"`
+	Id                       string            `yaml:"id"`
+	Backend                  types.BackendType `yaml:"backend"`
+	RedisConnectionId        string            `yaml:"redisConnectionId"`
+	MaxEntries               int               `yaml:"maxEntries"`
+	Key                      string            `yaml:"key"`
+	TtlInSeconds             int               `yaml:"ttlInSeconds"`
+	FingerprintKeys          []string          `yaml:"fingerprintKeys"`
+	StatusCodes              []int             `yaml:"statusCodes"`
+	InFlightTimeoutInSeconds int               `yaml:"inFlightTimeoutInSeconds"`
+	RetryAfterInSeconds      int               `yaml:"retryAfterInSeconds"`
 }
 
 func (setting *IdempSettings) GetId() string {
 	return setting.Id
 }
 
+func (setting *IdempSettings) GetBackend() types.BackendType {
+	if len(setting.Backend) == 0 {
+		return types.BackendTypeRedis
+	}
+	return setting.Backend
+}
+
+// IsStatusCodeCacheable defaults to every non 5xx response, so server errors can be retried.
+func (setting *IdempSettings) IsStatusCodeCacheable(statusCode int) bool {
+	if len(setting.StatusCodes) == 0 {
+		return statusCode < 500
+	}
+
+	for _, code := range setting.StatusCodes {
+		if code == statusCode {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (setting *IdempSettings) GetInFlightTimeout() time.Duration {
+	if setting.InFlightTimeoutInSeconds <= 0 {
+		return 20 * time.Second
+	}
+	return time.Duration(setting.InFlightTimeoutInSeconds) * time.Second
+}
+
+func (setting *IdempSettings) GetRetryAfterInSeconds() int {
+	if setting.RetryAfterInSeconds <= 0 {
+		return 1
+	}
+	return setting.RetryAfterInSeconds
+}
+
 func (setting *IdempSettings) Valid() validation.ValidateResult {
 	var result validation.ValidateResult
 
@@ -20,10 +67,21 @@ func (setting *IdempSettings) Valid() validation.ValidateResult {
 		result.AddError("idemp.id is required")
 	}
 
-	if len(setting.RedisConnectionId) == 0 {
+	if setting.GetBackend() == types.BackendTypeRedis &&
+		len(setting.RedisConnectionId) == 0 {
 		result.AddError("idemp.redisConnectionId is required")
 	}
 
+	if setting.Backend != "" &&
+		setting.Backend != types.BackendTypeRedis &&
+		setting.Backend != types.BackendTypeMemory {
+		result.AddError(fmt.Sprintf("idemps[%v].backend should be redis or memory", setting.Id))
+	}
+
+	if setting.MaxEntries < 0 {
+		result.AddError(fmt.Sprintf("idemps[%v].maxEntries can't be negative", setting.Id))
+	}
+
 	if len(setting.Key) == 0 {
 		result.AddError("idemp.key is required")
 	}
@@ -32,5 +90,19 @@ func (setting *IdempSettings) Valid() validation.ValidateResult {
 		result.AddError("idemp.ttlInSeconds should be greater than 59")
 	}
 
+	for _, statusCode := range setting.StatusCodes {
+		if statusCode < 100 || statusCode > 599 {
+			result.AddError(fmt.Sprintf("idemps[%v].statusCodes %v is not a valid http status code", setting.Id, statusCode))
+		}
+	}
+
+	if setting.InFlightTimeoutInSeconds < 0 {
+		result.AddError(fmt.Sprintf("idemps[%v].inFlightTimeoutInSeconds can't be negative", setting.Id))
+	}
+
+	if setting.RetryAfterInSeconds < 0 {
+		result.AddError(fmt.Sprintf("idemps[%v].retryAfterInSeconds can't be negative", setting.Id))
+	}
+
 	return result
 }
@@ -1,23 +1,81 @@
 package key_settings
 
-import "wrench/app/manifest/validation"
+import (
+	"slices"
+	"wrench/app/manifest/validation"
+)
 
+// KeySettings loads a private key (RSA, EC P-256/P-384 or Ed25519) from exactly one source,
+// the PEM may be PKCS#8, PKCS#1 or SEC1. Keys of partners only have the public part (PKIX),
+// they can verify but can't sign. secretKeyBase64 is a AES-256 key (32 bytes) for encryption.
+// publishJwks adds the public part to /.well-known/jwks.json, kid is also the default kid of the
+// tokens signed with the key, so a new key can be published before it signs and the old one
+// removed after its tokens expire.
 type KeySettings struct {
 	Id                     string `yaml:"id"`
+	Kid                    string `yaml:"kid"`
+	PublishJwks            bool   `yaml:"publishJwks"`
+	Use                    string `yaml:"use"`
 	PrivateRsaKeyDERBase64 string `yaml:"privateRsaKeyDERBase64"`
+	PrivateKeyPem          string `yaml:"privateKeyPem"`
+	PrivateKeyPemFile      string `yaml:"privateKeyPemFile"`
+	PrivateKeyPemEnv       string `yaml:"privateKeyPemEnv"`
+	PublicKeyPem           string `yaml:"publicKeyPem"`
+	PublicKeyPemFile       string `yaml:"publicKeyPemFile"`
+	PublicKeyPemEnv        string `yaml:"publicKeyPemEnv"`
+	SecretKeyBase64        string `yaml:"secretKeyBase64"`
 }
 
 func (setting *KeySettings) GetId() string {
 	return setting.Id
 }
 
+func (setting *KeySettings) GetKid() string {
+	if len(setting.Kid) == 0 {
+		return setting.Id
+	}
+	return setting.Kid
+}
+
+func (setting *KeySettings) GetUse() string {
+	if len(setting.Use) == 0 {
+		return "sig"
+	}
+	return setting.Use
+}
+
+func (setting *KeySettings) IsPublicOnly() bool {
+	return len(setting.PublicKeyPem) > 0 || len(setting.PublicKeyPemFile) > 0 || len(setting.PublicKeyPemEnv) > 0
+}
+
+func (setting *KeySettings) IsSecret() bool {
+	return len(setting.SecretKeyBase64) > 0
+}
+
 func (setting KeySettings) Valid() validation.ValidateResult {
 	var result validation.ValidateResult
 	if len(setting.Id) == 0 {
 		result.AddError("keySettings.id is required")
 	}
-	if len(setting.PrivateRsaKeyDERBase64) == 0 {
-		result.AddError("keySettings.privateRsaKeyDERBase64 is required")
+
+	sources := 0
+	for _, source := range []string{setting.PrivateRsaKeyDERBase64, setting.PrivateKeyPem, setting.PrivateKeyPemFile, setting.PrivateKeyPemEnv,
+		setting.PublicKeyPem, setting.PublicKeyPemFile, setting.PublicKeyPemEnv, setting.SecretKeyBase64} {
+		if len(source) > 0 {
+			sources++
+		}
+	}
+
+	if sources != 1 {
+		result.AddError("keySettings should contain one of privateRsaKeyDERBase64, privateKeyPem, privateKeyPemFile, privateKeyPemEnv, publicKeyPem, publicKeyPemFile, publicKeyPemEnv or secretKeyBase64")
+	}
+
+	if setting.PublishJwks && setting.IsSecret() {
+		result.AddError("keySettings.publishJwks can't be used with secretKeyBase64")
+	}
+
+	if !slices.Contains([]string{"sig", "enc"}, setting.GetUse()) {
+		result.AddError("keySettings.use should be sig or enc")
 	}
 
 	return result
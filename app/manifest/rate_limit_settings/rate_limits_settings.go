@@ -1,21 +1,68 @@
 package rate_limit_settings
 
-import "wrench/app/manifest/validation"
+import (
+	"fmt"
+	"wrench/app/manifest/types"
+	"wrench/app/manifest/validation"
+)
+
+type RateLimitAlgorithm string
+
+const (
+	RateLimitAlgorithmTokenBucket   RateLimitAlgorithm = "tokenBucket"
+	RateLimitAlgorithmSlidingWindow RateLimitAlgorithm = "slidingWindow"
+)
 
 type RateLimitSettings struct {
-	Id                string   `yaml:"id"`
-	RedisConnectionId string   `yaml:"redisConnectionId"`
-	RouteEnabled      bool     `yaml:"routeEnabled"`
-	Keys              []string `yaml:"keys"`
-	RequestsPerSecond int      `yaml:"requestsPerSecond"`
-	RequestsPerMinute int      `yaml:"requestsPerMinute"`
-	BurstLimit        int      `yaml:"burstLimit"`
+	Id                string                  `yaml:"id"`
+	Backend           types.BackendType       `yaml:"backend"`
+	RedisConnectionId string                  `yaml:"redisConnectionId"`
+	MaxEntries        int                     `yaml:"maxEntries"`
+	Algorithm         RateLimitAlgorithm      `yaml:"algorithm"`
+	Tier              string                  `yaml:"tier"`
+	RouteEnabled      bool                    `yaml:"routeEnabled"`
+	Keys              []string                `yaml:"keys"`
+	RequestsPerSecond int                     `yaml:"requestsPerSecond"`
+	RequestsPerMinute int                     `yaml:"requestsPerMinute"`
+	RequestsPerHour   int                     `yaml:"requestsPerHour"`
+	BurstLimit        int                     `yaml:"burstLimit"`
+	Quota             *RateLimitQuotaSettings `yaml:"quota"`
+}
+
+type RateLimitQuotaSettings struct {
+	RequestsPerDay   int `yaml:"requestsPerDay"`
+	RequestsPerMonth int `yaml:"requestsPerMonth"`
 }
 
 func (setting *RateLimitSettings) GetId() string {
 	return setting.Id
 }
 
+func (setting *RateLimitSettings) GetBackend() types.BackendType {
+	if len(setting.Backend) == 0 {
+		return types.BackendTypeRedis
+	}
+	return setting.Backend
+}
+
+func (setting *RateLimitSettings) GetAlgorithm() RateLimitAlgorithm {
+	if len(setting.Algorithm) == 0 {
+		return RateLimitAlgorithmTokenBucket
+	}
+	return setting.Algorithm
+}
+
+// AppliesToTier is true when the limiter has no tier or matches the consumer rate limit tier.
+func (setting *RateLimitSettings) AppliesToTier(tier string) bool {
+	return len(setting.Tier) == 0 || setting.Tier == tier
+}
+
+func (setting *RateLimitSettings) HasRate() bool {
+	return setting.RequestsPerSecond > 0 ||
+		setting.RequestsPerMinute > 0 ||
+		setting.RequestsPerHour > 0
+}
+
 func (setting *RateLimitSettings) Valid() validation.ValidateResult {
 	var result validation.ValidateResult
 
@@ -28,12 +75,56 @@ func (setting *RateLimitSettings) Valid() validation.ValidateResult {
 		result.AddError("should set at least one of keys or enable routeEnabled")
 	}
 
-	if setting.RedisConnectionId == "" {
+	if setting.GetBackend() == types.BackendTypeRedis &&
+		setting.RedisConnectionId == "" {
 		result.AddError("should set redisConnectionId")
 	}
 
-	if setting.RequestsPerSecond >= 0 && setting.RequestsPerMinute >= 0 {
-		result.AddError("should set requestsPerSecond or requestsPerMinute, not both")
+	ratesInformed := 0
+	for _, rate := range []int{setting.RequestsPerSecond, setting.RequestsPerMinute, setting.RequestsPerHour} {
+		if rate > 0 {
+			ratesInformed++
+		}
+	}
+
+	if ratesInformed > 1 {
+		result.AddError("should set only one of requestsPerSecond, requestsPerMinute or requestsPerHour")
+	}
+
+	if ratesInformed == 0 && setting.Quota == nil {
+		result.AddError("should set requestsPerSecond, requestsPerMinute, requestsPerHour or quota")
+	}
+
+	if setting.Algorithm != "" &&
+		setting.Algorithm != RateLimitAlgorithmTokenBucket &&
+		setting.Algorithm != RateLimitAlgorithmSlidingWindow {
+		result.AddError(fmt.Sprintf("rateLimits[%v].algorithm should be tokenBucket or slidingWindow", setting.Id))
+	}
+
+	if setting.BurstLimit < 0 {
+		result.AddError(fmt.Sprintf("rateLimits[%v].burstLimit can't be negative", setting.Id))
+	} else if setting.BurstLimit > 0 && setting.GetAlgorithm() != RateLimitAlgorithmTokenBucket {
+		result.AddError(fmt.Sprintf("rateLimits[%v].burstLimit is only allowed with tokenBucket algorithm", setting.Id))
+	}
+
+	if setting.Quota != nil {
+		if setting.Quota.RequestsPerDay < 0 || setting.Quota.RequestsPerMonth < 0 {
+			result.AddError(fmt.Sprintf("rateLimits[%v].quota can't be negative", setting.Id))
+		}
+
+		if setting.Quota.RequestsPerDay == 0 && setting.Quota.RequestsPerMonth == 0 {
+			result.AddError(fmt.Sprintf("rateLimits[%v].quota should set requestsPerDay or requestsPerMonth", setting.Id))
+		}
+	}
+
+	if setting.Backend != "" &&
+		setting.Backend != types.BackendTypeRedis &&
+		setting.Backend != types.BackendTypeMemory {
+		result.AddError(fmt.Sprintf("rateLimits[%v].backend should be redis or memory", setting.Id))
+	}
+
+	if setting.MaxEntries < 0 {
+		result.AddError(fmt.Sprintf("rateLimits[%v].maxEntries can't be negative", setting.Id))
 	}
 
 	return result
@@ -0,0 +1,50 @@
+package secret_settings
+
+import (
+	"time"
+	"wrench/app/manifest/validation"
+)
+
+const defaultFileBasePath = "/var/run/secrets"
+
+// SecretSettings configures the providers of the refs {{secret:provider:path#field}},
+// file works without settings and the aws (Secrets Manager) one uses service.aws.region.
+// refreshIntervalInSeconds polls the secrets to pick up rotations, 0 reads them only on startup.
+type SecretSettings struct {
+	File                     *FileSecretSettings  `yaml:"file"`
+	Vault                    *VaultSecretSettings `yaml:"vault"`
+	Ssm                      *SsmSecretSettings   `yaml:"ssm"`
+	RefreshIntervalInSeconds int                  `yaml:"refreshIntervalInSeconds"`
+}
+
+type FileSecretSettings struct {
+	BasePath string `yaml:"basePath"`
+}
+
+func (setting *FileSecretSettings) GetBasePath() string {
+	if setting == nil || len(setting.BasePath) == 0 {
+		return defaultFileBasePath
+	}
+	return setting.BasePath
+}
+
+func (setting *SecretSettings) GetRefreshInterval() time.Duration {
+	if setting == nil {
+		return 0
+	}
+	return time.Duration(setting.RefreshIntervalInSeconds) * time.Second
+}
+
+func (setting SecretSettings) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if setting.RefreshIntervalInSeconds < 0 {
+		result.AddError("service.secrets.refreshIntervalInSeconds can't be negative")
+	}
+
+	if setting.Vault != nil {
+		result.AppendValidable(setting.Vault)
+	}
+
+	return result
+}
@@ -0,0 +1,6 @@
+package secret_settings
+
+// SsmSecretSettings region fallback to service.aws.region.
+type SsmSecretSettings struct {
+	Region string `yaml:"region"`
+}
@@ -0,0 +1,64 @@
+package secret_settings
+
+import (
+	"os"
+	"wrench/app/manifest/validation"
+)
+
+// VaultSecretSettings reads the KV engine mounted at mount, address and token fallback
+// to VAULT_ADDR and VAULT_TOKEN.
+type VaultSecretSettings struct {
+	Address          string `yaml:"address"`
+	Token            string `yaml:"token"`
+	TokenFile        string `yaml:"tokenFile"`
+	Namespace        string `yaml:"namespace"`
+	Mount            string `yaml:"mount"`
+	KvVersion        int    `yaml:"kvVersion"`
+	TimeoutInSeconds int    `yaml:"timeoutInSeconds"`
+}
+
+func (setting VaultSecretSettings) GetAddress() string {
+	if len(setting.Address) == 0 {
+		return os.Getenv("VAULT_ADDR")
+	}
+	return setting.Address
+}
+
+func (setting VaultSecretSettings) GetMount() string {
+	if len(setting.Mount) == 0 {
+		return "secret"
+	}
+	return setting.Mount
+}
+
+func (setting VaultSecretSettings) GetKvVersion() int {
+	if setting.KvVersion == 0 {
+		return 2
+	}
+	return setting.KvVersion
+}
+
+func (setting VaultSecretSettings) GetTimeoutInSeconds() int {
+	if setting.TimeoutInSeconds <= 0 {
+		return 10
+	}
+	return setting.TimeoutInSeconds
+}
+
+func (setting VaultSecretSettings) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if len(setting.GetAddress()) == 0 {
+		result.AddError("service.secrets.vault.address or the env VAULT_ADDR is required")
+	}
+
+	if len(setting.Token) > 0 && len(setting.TokenFile) > 0 {
+		result.AddError("service.secrets.vault should use token or tokenFile not both")
+	}
+
+	if kvVersion := setting.GetKvVersion(); kvVersion != 1 && kvVersion != 2 {
+		result.AddError("service.secrets.vault.kvVersion should be 1 or 2")
+	}
+
+	return result
+}
@@ -3,14 +3,16 @@ package service_settings
 import (
 	"wrench/app/manifest/aws_settings"
 	"wrench/app/manifest/otel_settings"
+	"wrench/app/manifest/secret_settings"
 	"wrench/app/manifest/validation"
 )
 
 type ServiceSettings struct {
-	Name    string                      `yaml:"name"`
-	Version string                      `yaml:"version"`
-	Otel    *otel_settings.OtelSettings `yaml:"otel"`
-	Aws     *aws_settings.AwsSettings   `yaml:"aws"`
+	Name    string                          `yaml:"name"`
+	Version string                          `yaml:"version"`
+	Otel    *otel_settings.OtelSettings     `yaml:"otel"`
+	Aws     *aws_settings.AwsSettings       `yaml:"aws"`
+	Secrets *secret_settings.SecretSettings `yaml:"secrets"`
 }
 
 func (setting ServiceSettings) Valid() validation.ValidateResult {
@@ -32,5 +34,9 @@ func (setting ServiceSettings) Valid() validation.ValidateResult {
 		result.AppendValidable(setting.Aws)
 	}
 
+	if setting.Secrets != nil {
+		result.AppendValidable(setting.Secrets)
+	}
+
 	return result
 }
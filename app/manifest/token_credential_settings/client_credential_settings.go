@@ -6,6 +6,7 @@ type ClientCredentialSetting struct {
 	ClientId     string `yaml:"clientId"`
 	ClientSecret string `yaml:"clientSecret"`
 	Audiance     string `yaml:"audiance"`
+	Scope        string `yaml:"scope"`
 }
 
 func (setting ClientCredentialSetting) Valid() validation.ValidateResult {
@@ -0,0 +1,42 @@
+package token_credential_settings
+
+import "wrench/app/manifest/validation"
+
+// JwtBearerSetting uses the jwt-bearer grant (RFC 7523), the assertion is signed by keyId from keys.
+// Claims are added to the assertion, ex: a service account with iss and sub.
+type JwtBearerSetting struct {
+	Issuer                   string            `yaml:"issuer"`
+	Subject                  string            `yaml:"subject"`
+	Audience                 string            `yaml:"audience"`
+	KeyId                    string            `yaml:"keyId"`
+	Kid                      string            `yaml:"kid"`
+	Algorithm                string            `yaml:"algorithm"`
+	Scope                    string            `yaml:"scope"`
+	Claims                   map[string]string `yaml:"claims"`
+	ClientId                 string            `yaml:"clientId"`
+	ClientSecret             string            `yaml:"clientSecret"`
+	AssertionLifetimeSeconds int               `yaml:"assertionLifetimeSeconds"`
+}
+
+func (setting JwtBearerSetting) GetAlgorithm() string {
+	if len(setting.Algorithm) == 0 {
+		return "RS256"
+	}
+	return setting.Algorithm
+}
+
+func (setting JwtBearerSetting) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if len(setting.Issuer) == 0 {
+		result.AddError("tokenCredentials.jwtBearer.issuer is required")
+	}
+
+	if len(setting.KeyId) == 0 {
+		result.AddError("tokenCredentials.jwtBearer.keyId is required")
+	}
+
+	result.Append(assertionValid("jwtBearer", setting.GetAlgorithm(), setting.AssertionLifetimeSeconds))
+
+	return result
+}
@@ -0,0 +1,29 @@
+package token_credential_settings
+
+import "wrench/app/manifest/validation"
+
+type PasswordSetting struct {
+	ClientId     string `yaml:"clientId"`
+	ClientSecret string `yaml:"clientSecret"`
+	Username     string `yaml:"username"`
+	Password     string `yaml:"password"`
+	Scope        string `yaml:"scope"`
+}
+
+func (setting PasswordSetting) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if len(setting.ClientId) == 0 {
+		result.AddError("tokenCredentials.password.clientId is required")
+	}
+
+	if len(setting.Username) == 0 {
+		result.AddError("tokenCredentials.password.username is required")
+	}
+
+	if len(setting.Password) == 0 {
+		result.AddError("tokenCredentials.password.password is required")
+	}
+
+	return result
+}
@@ -0,0 +1,64 @@
+package token_credential_settings
+
+import (
+	"fmt"
+	"wrench/app/manifest/validation"
+)
+
+var supportedAssertionAlgorithms = []string{"RS256", "RS512", "PS256", "ES256", "ES384", "EdDSA"}
+
+// PrivateKeyJwtSetting authenticates the client_credentials grant with a client assertion (RFC 7523)
+// signed by keyId from keys, audience defaults to the authEndpoint.
+type PrivateKeyJwtSetting struct {
+	ClientId                 string `yaml:"clientId"`
+	KeyId                    string `yaml:"keyId"`
+	Kid                      string `yaml:"kid"`
+	Algorithm                string `yaml:"algorithm"`
+	Audience                 string `yaml:"audience"`
+	Scope                    string `yaml:"scope"`
+	AssertionLifetimeSeconds int    `yaml:"assertionLifetimeSeconds"`
+}
+
+func (setting PrivateKeyJwtSetting) GetAlgorithm() string {
+	if len(setting.Algorithm) == 0 {
+		return "RS256"
+	}
+	return setting.Algorithm
+}
+
+func (setting PrivateKeyJwtSetting) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if len(setting.ClientId) == 0 {
+		result.AddError("tokenCredentials.privateKeyJwt.clientId is required")
+	}
+
+	if len(setting.KeyId) == 0 {
+		result.AddError("tokenCredentials.privateKeyJwt.keyId is required")
+	}
+
+	result.Append(assertionValid("privateKeyJwt", setting.GetAlgorithm(), setting.AssertionLifetimeSeconds))
+
+	return result
+}
+
+func assertionValid(name string, algorithm string, lifetimeSeconds int) validation.ValidateResult {
+	var result validation.ValidateResult
+
+	supported := false
+	for _, supportedAlgorithm := range supportedAssertionAlgorithms {
+		if algorithm == supportedAlgorithm {
+			supported = true
+		}
+	}
+
+	if !supported {
+		result.AddError(fmt.Sprintf("tokenCredentials.%v.algorithm should be one of %v", name, supportedAssertionAlgorithms))
+	}
+
+	if lifetimeSeconds < 0 {
+		result.AddError(fmt.Sprintf("tokenCredentials.%v.assertionLifetimeSeconds can't be negative", name))
+	}
+
+	return result
+}
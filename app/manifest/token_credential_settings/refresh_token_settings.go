@@ -0,0 +1,26 @@
+package token_credential_settings
+
+import "wrench/app/manifest/validation"
+
+// RefreshTokenSetting starts from a provisioned refresh token (ex: an offline token), when the
+// server rotates it the new one is used in the next refresh.
+type RefreshTokenSetting struct {
+	ClientId     string `yaml:"clientId"`
+	ClientSecret string `yaml:"clientSecret"`
+	RefreshToken string `yaml:"refreshToken"`
+	Scope        string `yaml:"scope"`
+}
+
+func (setting RefreshTokenSetting) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if len(setting.ClientId) == 0 {
+		result.AddError("tokenCredentials.refreshToken.clientId is required")
+	}
+
+	if len(setting.RefreshToken) == 0 {
+		result.AddError("tokenCredentials.refreshToken.refreshToken is required")
+	}
+
+	return result
+}
@@ -3,6 +3,7 @@ package token_credential_settings
 import (
 	"strconv"
 	"strings"
+	"time"
 	"wrench/app/manifest/validation"
 )
 
@@ -15,18 +16,37 @@ type TokenCredentialSetting struct {
 	Basic            *BasicSetting            `yaml:"basic"`
 	ForceReload      string                   `yaml:"forceReload"`
 	Custom           *CustomAuthentication    `yaml:"custom"`
+	PrivateKeyJwt    *PrivateKeyJwtSetting    `yaml:"privateKeyJwt"`
+	JwtBearer        *JwtBearerSetting        `yaml:"jwtBearer"`
+	Password         *PasswordSetting         `yaml:"password"`
+	RefreshToken     *RefreshTokenSetting     `yaml:"refreshToken"`
+	Store            *TokenStoreSetting       `yaml:"store"`
+
+	RefreshBeforeExpirySeconds int `yaml:"refreshBeforeExpirySeconds"`
 }
 
 func (setting *TokenCredentialSetting) GetId() string {
 	return setting.Id
 }
 
+// GetRefreshBeforeExpiry is how long before exp the token is proactively refreshed, default 5 minutes.
+func (setting *TokenCredentialSetting) GetRefreshBeforeExpiry() time.Duration {
+	if setting.RefreshBeforeExpirySeconds <= 0 {
+		return 5 * time.Minute
+	}
+	return time.Duration(setting.RefreshBeforeExpirySeconds) * time.Second
+}
+
 type TokenCredentialType string
 
 const (
 	TokenCredentialClientCredential     TokenCredentialType = "client_credentials"
 	TokenCredentialBasicCredential      TokenCredentialType = "basic"
 	TokenCredentialCustomAuthentication TokenCredentialType = "custom_authentication"
+	TokenCredentialPrivateKeyJwt        TokenCredentialType = "private_key_jwt"
+	TokenCredentialJwtBearer            TokenCredentialType = "jwt_bearer"
+	TokenCredentialPassword             TokenCredentialType = "password"
+	TokenCredentialRefreshToken         TokenCredentialType = "refresh_token"
 )
 
 var forceReloadTimeSelector string = ""
@@ -81,6 +101,14 @@ func (setting TokenCredentialSetting) Valid() validation.ValidateResult {
 		result.AddError("tokenCredentials.authEndpoint is required")
 	}
 
+	if setting.Store != nil {
+		result.AppendValidable(setting.Store)
+	}
+
+	if setting.RefreshBeforeExpirySeconds < 0 {
+		result.AddError("tokenCredentials.refreshBeforeExpirySeconds can't be negative")
+	}
+
 	if setting.Type == TokenCredentialClientCredential {
 
 		if setting.ClientCredential == nil {
@@ -99,6 +127,38 @@ func (setting TokenCredentialSetting) Valid() validation.ValidateResult {
 		}
 	}
 
+	if setting.Type == TokenCredentialPrivateKeyJwt {
+		if setting.PrivateKeyJwt == nil {
+			result.AddError("tokenCredentials.privateKeyJwt is required when type is private_key_jwt")
+		} else {
+			result.AppendValidable(setting.PrivateKeyJwt)
+		}
+	}
+
+	if setting.Type == TokenCredentialJwtBearer {
+		if setting.JwtBearer == nil {
+			result.AddError("tokenCredentials.jwtBearer is required when type is jwt_bearer")
+		} else {
+			result.AppendValidable(setting.JwtBearer)
+		}
+	}
+
+	if setting.Type == TokenCredentialPassword {
+		if setting.Password == nil {
+			result.AddError("tokenCredentials.password is required when type is password")
+		} else {
+			result.AppendValidable(setting.Password)
+		}
+	}
+
+	if setting.Type == TokenCredentialRefreshToken {
+		if setting.RefreshToken == nil {
+			result.AddError("tokenCredentials.refreshToken is required when type is refresh_token")
+		} else {
+			result.AppendValidable(setting.RefreshToken)
+		}
+	}
+
 	if setting.Type == TokenCredentialCustomAuthentication {
 		if len(setting.ForceReload) == 0 {
 			result.AddError("tokenCredentials.ForceReload is required when type is custom_authentication")
@@ -0,0 +1,34 @@
+package token_credential_settings
+
+import (
+	"encoding/base64"
+	"wrench/app/manifest/validation"
+)
+
+// TokenStoreSetting shares the token between replicas in redis, encrypted with AES-256-GCM.
+// encryptionKey is base64 of 32 bytes, ex: openssl rand -base64 32
+type TokenStoreSetting struct {
+	RedisConnectionId string `yaml:"redisConnectionId"`
+	EncryptionKey     string `yaml:"encryptionKey"`
+}
+
+func (setting TokenStoreSetting) GetEncryptionKey() []byte {
+	key, _ := base64.StdEncoding.DecodeString(setting.EncryptionKey)
+	return key
+}
+
+func (setting TokenStoreSetting) Valid() validation.ValidateResult {
+	var result validation.ValidateResult
+
+	if len(setting.RedisConnectionId) == 0 {
+		result.AddError("tokenCredentials.store.redisConnectionId is required")
+	}
+
+	if len(setting.EncryptionKey) == 0 {
+		result.AddError("tokenCredentials.store.encryptionKey is required")
+	} else if len(setting.GetEncryptionKey()) != 32 {
+		result.AddError("tokenCredentials.store.encryptionKey should be base64 of 32 bytes")
+	}
+
+	return result
+}
@@ -18,3 +18,44 @@ const (
 	HashAlgSHA1   HashAlg = "SHA-1"
 	HashAlgMD5    HashAlg = "MD5"
 )
+
+// SignatureAlg follows the JWA names (RFC 7518), the signatures are in the JWS format.
+type SignatureAlg string
+
+const (
+	SignatureAlgRS256 SignatureAlg = "RS256"
+	SignatureAlgRS512 SignatureAlg = "RS512"
+	SignatureAlgPS256 SignatureAlg = "PS256"
+	SignatureAlgES256 SignatureAlg = "ES256"
+	SignatureAlgES384 SignatureAlg = "ES384"
+	SignatureAlgEdDSA SignatureAlg = "EdDSA"
+)
+
+type EncodingType string
+
+const (
+	EncodingTypeRaw       EncodingType = "raw"
+	EncodingTypeBase64    EncodingType = "base64"
+	EncodingTypeBase64Url EncodingType = "base64url"
+	EncodingTypeHex       EncodingType = "hex"
+)
+
+// EncryptionAlg A256GCM uses a secret key, the others produce a JWE compact with enc A256GCM.
+type EncryptionAlg string
+
+const (
+	EncryptionAlgA256GCM    EncryptionAlg = "A256GCM"
+	EncryptionAlgRsaOaep256 EncryptionAlg = "RSA-OAEP-256"
+	EncryptionAlgEcdhEs     EncryptionAlg = "ECDH-ES"
+)
+
+var SupportedEncryptionAlgorithms = []EncryptionAlg{
+	EncryptionAlgA256GCM, EncryptionAlgRsaOaep256, EncryptionAlgEcdhEs,
+}
+
+type BackendType string
+
+const (
+	BackendTypeRedis  BackendType = "redis"
+	BackendTypeMemory BackendType = "memory"
+)
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"wrench/app/manifest/application_settings"
+	"wrench/app/manifest/cache_settings"
 	"wrench/app/manifest/connection_settings"
 	"wrench/app/manifest/idemp_settings"
 	"wrench/app/manifest/key_settings"
@@ -82,6 +83,20 @@ func GetRateLimitSettingById(rateLimitId string) (*rate_limit_settings.RateLimit
 	return nil, fmt.Errorf("rateLimitId %s not found", rateLimitId)
 }
 
+func GetCacheSettingById(cacheId string) (*cache_settings.CacheSettings, error) {
+	appSetting := application_settings.ApplicationSettingsStatic
+
+	if len(appSetting.Caches) > 0 {
+		for _, cache := range appSetting.Caches {
+			if cache.Id == cacheId {
+				return cache, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("cacheId %s not found", cacheId)
+}
+
 func GetService() *service_settings.ServiceSettings {
 	appSetting := application_settings.ApplicationSettingsStatic
 	return appSetting.Service
@@ -116,6 +131,18 @@ func GetDynamoDbTableSettings(tableId string) (*connection_settings.DynamoDbTabl
 }
 
 func GetPrivateKeyById(keyId string) (*key_settings.KeySettings, error) {
+	key, err := GetKeyById(keyId)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.IsPublicOnly() || key.IsSecret() {
+		return nil, fmt.Errorf("key %s isn't a private key", keyId)
+	}
+	return key, nil
+}
+
+func GetKeyById(keyId string) (*key_settings.KeySettings, error) {
 	appSetting := application_settings.ApplicationSettingsStatic
 	if len(appSetting.Keys) > 0 {
 		for _, key := range appSetting.Keys {
@@ -125,5 +152,5 @@ func GetPrivateKeyById(keyId string) (*key_settings.KeySettings, error) {
 		}
 
 	}
-	return nil, fmt.Errorf("private key %s not found", keyId)
+	return nil, fmt.Errorf("key %s not found", keyId)
 }
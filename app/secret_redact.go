@@ -0,0 +1,54 @@
+package app
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+const redactedValue = "***"
+
+// secrets shorter than this would redact common words of the logs
+const minRedactLength = 4
+
+var secretValues = make(map[string]bool)
+var secretRedactor *strings.Replacer
+var secretRedactorMutex sync.RWMutex
+
+// RegisterSecretValue makes the logs replace value by ***.
+func RegisterSecretValue(value string) {
+	if len(value) < minRedactLength {
+		return
+	}
+
+	secretRedactorMutex.Lock()
+	defer secretRedactorMutex.Unlock()
+
+	if secretValues[value] {
+		return
+	}
+	secretValues[value] = true
+
+	values := make([]string, 0, len(secretValues))
+	for secretValue := range secretValues {
+		values = append(values, secretValue)
+	}
+	// the replacer tries the values in order, a secret prefix of another would leave the rest of the longer one
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+
+	oldNew := make([]string, 0, len(values)*2)
+	for _, secretValue := range values {
+		oldNew = append(oldNew, secretValue, redactedValue)
+	}
+	secretRedactor = strings.NewReplacer(oldNew...)
+}
+
+func RedactSecrets(text string) string {
+	secretRedactorMutex.RLock()
+	defer secretRedactorMutex.RUnlock()
+
+	if secretRedactor == nil {
+		return text
+	}
+	return secretRedactor.Replace(text)
+}
@@ -0,0 +1,33 @@
+package app
+
+import "testing"
+
+func TestRedactSecretsPrefixOfAnother(t *testing.T) {
+	RegisterSecretValue("token-abc")
+	RegisterSecretValue("token-abc-123456")
+	RegisterSecretValue("pass-987654")
+	RegisterSecretValue("pass")
+
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"longer secret", "auth failed token-abc-123456 end", "auth failed *** end"},
+		{"shorter secret", "auth failed token-abc end", "auth failed *** end"},
+		{"longer registered first", "password pass-987654", "***word ***"},
+		{"not a secret", "nothing here", "nothing here"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// each register rebuilds the replacer from the map, the repeats cover other iteration orders
+			for i := 0; i < 20; i++ {
+				RegisterSecretValue("other-secret-" + string(rune('a'+i)))
+				if result := RedactSecrets(test.text); result != test.expected {
+					t.Fatalf("expected %q, got %q", test.expected, result)
+				}
+			}
+		})
+	}
+}
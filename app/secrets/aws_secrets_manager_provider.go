@@ -0,0 +1,52 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+type awsSecretsManagerProvider struct {
+	region string
+	client *secretsmanager.Client
+	mutex  sync.Mutex
+}
+
+func newAwsSecretsManagerProvider(region string) *awsSecretsManagerProvider {
+	return &awsSecretsManagerProvider{region: region}
+}
+
+func (provider *awsSecretsManagerProvider) GetSecret(ctx context.Context, path string) (string, error) {
+	client, err := provider.getClient(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId:     aws.String(path),
+		VersionStage: aws.String("AWSCURRENT"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("aws secret %v: %w", path, err)
+	}
+
+	return aws.ToString(output.SecretString), nil
+}
+
+func (provider *awsSecretsManagerProvider) getClient(ctx context.Context) (*secretsmanager.Client, error) {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	if provider.client == nil {
+		awsConfig, err := config.LoadDefaultConfig(ctx, config.WithRegion(provider.region))
+		if err != nil {
+			return nil, err
+		}
+		provider.client = secretsmanager.NewFromConfig(awsConfig)
+	}
+	return provider.client, nil
+}
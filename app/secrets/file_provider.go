@@ -0,0 +1,32 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fileProvider reads mounted secrets, in Kubernetes each key of the secret is a file.
+type fileProvider struct {
+	basePath string
+}
+
+func newFileProvider(basePath string) *fileProvider {
+	return &fileProvider{basePath: basePath}
+}
+
+func (provider *fileProvider) GetSecret(ctx context.Context, path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(provider.basePath, path)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read secret file: %w", err)
+	}
+
+	// editors and kubectl create-secret from files usually leave the last new line
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
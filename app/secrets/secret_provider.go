@@ -0,0 +1,69 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"wrench/app/manifest/secret_settings"
+	"wrench/app/manifest/service_settings"
+)
+
+const (
+	ProviderFile  = "file"
+	ProviderVault = "vault"
+	ProviderSsm   = "ssm"
+	ProviderAws   = "aws"
+)
+
+// SecretProvider returns the secret at path as it's stored, JSON secrets are split
+// in fields by the ref.
+type SecretProvider interface {
+	GetSecret(ctx context.Context, path string) (string, error)
+}
+
+var providers = make(map[string]SecretProvider)
+var providersMutex sync.Mutex
+
+// LoadProviders configures the providers from service.secrets, file is always available.
+func LoadProviders(serviceSettings *service_settings.ServiceSettings) {
+	providersMutex.Lock()
+	defer providersMutex.Unlock()
+
+	var awsRegion string
+	var secretSettings secret_settings.SecretSettings
+	if serviceSettings != nil {
+		if serviceSettings.Aws != nil {
+			awsRegion = serviceSettings.Aws.Region
+		}
+		if serviceSettings.Secrets != nil {
+			secretSettings = *serviceSettings.Secrets
+		}
+	}
+
+	providers[ProviderFile] = newFileProvider(secretSettings.File.GetBasePath())
+
+	if secretSettings.Vault != nil {
+		providers[ProviderVault] = newVaultProvider(secretSettings.Vault)
+	}
+
+	if secretSettings.Ssm != nil {
+		region := secretSettings.Ssm.Region
+		if len(region) == 0 {
+			region = awsRegion
+		}
+		providers[ProviderSsm] = newSsmProvider(region)
+	}
+
+	providers[ProviderAws] = newAwsSecretsManagerProvider(awsRegion)
+}
+
+func GetProvider(name string) (SecretProvider, error) {
+	providersMutex.Lock()
+	defer providersMutex.Unlock()
+
+	provider, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("secret provider %v not configured in service.secrets", name)
+	}
+	return provider, nil
+}
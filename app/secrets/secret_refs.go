@@ -0,0 +1,197 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"sync"
+	"wrench/app"
+
+	"gopkg.in/yaml.v3"
+)
+
+// {{secret:provider:path#field}}, field is optional and reads a key of a JSON secret
+var secretRefRegex = regexp.MustCompile(`\{\{secret:([a-zA-Z0-9]+):([^#}]+)(?:#([^}]+))?\}\}`)
+
+// resolvedSecrets keeps the last values read by provider:path, the refresh compares them to detect rotations
+var resolvedSecrets map[string]string
+var resolvedSecretsMutex sync.Mutex
+
+type SecretRef struct {
+	Ref      string
+	Provider string
+	Path     string
+	Field    string
+}
+
+func FindSecretRefs(value []byte) []SecretRef {
+	var refs []SecretRef
+	for _, match := range secretRefRegex.FindAllStringSubmatch(string(value), -1) {
+		refs = append(refs, SecretRef{Ref: match[0], Provider: match[1], Path: match[2], Field: match[3]})
+	}
+	return refs
+}
+
+// ResolveSecretRefs replaces the refs of the config files, each secret is read once and the
+// refs not resolved are all reported in the error. The refs are replaced in the decoded yaml
+// strings, so values with quotes, colons or new lines (e.g. pem) don't change the yaml.
+func ResolveSecretRefs(ctx context.Context, files map[string][]byte) (map[string][]byte, error) {
+	secretsRead := make(map[string]string)
+	var errs []error
+
+	result := make(map[string][]byte)
+	for fileName, content := range files {
+		if !secretRefRegex.Match(content) {
+			result[fileName] = content
+			continue
+		}
+
+		resolved, err := replaceYamlSecretRefs(content, func(match string) string {
+			ref := FindSecretRefs([]byte(match))[0]
+
+			value, err := resolveSecretRef(ctx, ref, secretsRead)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%v: %w", ref.Ref, err))
+				return match
+			}
+
+			app.RegisterSecretValue(value)
+			return value
+		})
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: %w", fileName, err))
+			resolved = content
+		}
+		result[fileName] = resolved
+	}
+
+	if len(errs) == 0 {
+		resolvedSecretsMutex.Lock()
+		resolvedSecrets = secretsRead
+		resolvedSecretsMutex.Unlock()
+	}
+
+	return result, errors.Join(errs...)
+}
+
+func replaceYamlSecretRefs(content []byte, resolve func(match string) string) ([]byte, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+
+	for {
+		var document yaml.Node
+		if err := decoder.Decode(&document); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		replaceNodeSecretRefs(&document, resolve)
+
+		if err := encoder.Encode(&document); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func replaceNodeSecretRefs(node *yaml.Node, resolve func(match string) string) {
+	if node.Kind == yaml.ScalarNode && secretRefRegex.MatchString(node.Value) {
+		node.Value = secretRefRegex.ReplaceAllStringFunc(node.Value, resolve)
+		// the encoder picks a style able to keep the secret as it is
+		node.Style = 0
+		return
+	}
+
+	for _, child := range node.Content {
+		replaceNodeSecretRefs(child, resolve)
+	}
+}
+
+// RefreshSecretRefs reads the secrets again and resolves the config, rotated has the provider:path
+// of the secrets changed since the last resolution.
+func RefreshSecretRefs(ctx context.Context, files map[string][]byte) (rotated []string, result map[string][]byte, err error) {
+	resolvedSecretsMutex.Lock()
+	previous := resolvedSecrets
+	resolvedSecretsMutex.Unlock()
+
+	result, err = ResolveSecretRefs(ctx, files)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resolvedSecretsMutex.Lock()
+	for secretKey, value := range resolvedSecrets {
+		if previousValue, ok := previous[secretKey]; ok && previousValue != value {
+			rotated = append(rotated, secretKey)
+		}
+	}
+	resolvedSecretsMutex.Unlock()
+
+	sort.Strings(rotated)
+	return rotated, result, nil
+}
+
+func HasSecretRefs(files map[string][]byte) bool {
+	for _, content := range files {
+		if secretRefRegex.Match(content) {
+			return true
+		}
+	}
+	return false
+}
+
+func resolveSecretRef(ctx context.Context, ref SecretRef, secretsRead map[string]string) (string, error) {
+	secretKey := ref.Provider + ":" + ref.Path
+	secret, ok := secretsRead[secretKey]
+	if !ok {
+		provider, err := GetProvider(ref.Provider)
+		if err != nil {
+			return "", err
+		}
+
+		secret, err = provider.GetSecret(ctx, ref.Path)
+		if err != nil {
+			return "", err
+		}
+		secretsRead[secretKey] = secret
+	}
+
+	if len(ref.Field) == 0 {
+		return secret, nil
+	}
+
+	return getSecretField(secret, ref.Field)
+}
+
+func getSecretField(secret string, field string) (string, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", errors.New("secret isn't a JSON object, the field can't be read")
+	}
+
+	value, ok := fields[field]
+	if !ok {
+		return "", fmt.Errorf("field %v not found", field)
+	}
+
+	if stringValue, isString := value.(string); isString {
+		return stringValue, nil
+	}
+
+	valueJson, err := json.Marshal(value)
+	return string(valueJson), err
+}
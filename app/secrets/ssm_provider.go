@@ -0,0 +1,53 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// ssmProvider reads parameters of the Parameter Store, SecureString ones are decrypted.
+type ssmProvider struct {
+	region string
+	client *ssm.Client
+	mutex  sync.Mutex
+}
+
+func newSsmProvider(region string) *ssmProvider {
+	return &ssmProvider{region: region}
+}
+
+func (provider *ssmProvider) GetSecret(ctx context.Context, path string) (string, error) {
+	client, err := provider.getClient(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := client.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(path),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", fmt.Errorf("ssm parameter %v: %w", path, err)
+	}
+
+	return aws.ToString(output.Parameter.Value), nil
+}
+
+func (provider *ssmProvider) getClient(ctx context.Context) (*ssm.Client, error) {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	if provider.client == nil {
+		awsConfig, err := config.LoadDefaultConfig(ctx, config.WithRegion(provider.region))
+		if err != nil {
+			return nil, err
+		}
+		provider.client = ssm.NewFromConfig(awsConfig)
+	}
+	return provider.client, nil
+}
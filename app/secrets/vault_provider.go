@@ -0,0 +1,107 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+	"wrench/app/manifest/secret_settings"
+)
+
+// vaultProvider reads the KV engine over the HTTP API, the secret is returned as JSON of its data.
+type vaultProvider struct {
+	settings *secret_settings.VaultSecretSettings
+	client   *http.Client
+}
+
+func newVaultProvider(settings *secret_settings.VaultSecretSettings) *vaultProvider {
+	return &vaultProvider{
+		settings: settings,
+		client:   &http.Client{Timeout: time.Duration(settings.GetTimeoutInSeconds()) * time.Second},
+	}
+}
+
+func (provider *vaultProvider) GetSecret(ctx context.Context, path string) (string, error) {
+	settings := provider.settings
+
+	secretPath := strings.Trim(path, "/")
+	if settings.GetKvVersion() == 2 {
+		secretPath = "data/" + secretPath
+	}
+	secretUrl, err := url.JoinPath(settings.GetAddress(), "v1", settings.GetMount(), secretPath)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := provider.getToken()
+	if err != nil {
+		return "", err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, secretUrl, nil)
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("X-Vault-Token", token)
+	if len(settings.Namespace) > 0 {
+		request.Header.Set("X-Vault-Namespace", settings.Namespace)
+	}
+
+	response, err := provider.client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("vault request: %w", err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault secret %v returned status %v", path, response.StatusCode)
+	}
+
+	var vaultResponse struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &vaultResponse); err != nil {
+		return "", fmt.Errorf("vault response: %w", err)
+	}
+
+	if settings.GetKvVersion() == 1 {
+		return string(vaultResponse.Data), nil
+	}
+
+	var kvV2Data struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(vaultResponse.Data, &kvV2Data); err != nil {
+		return "", fmt.Errorf("vault response: %w", err)
+	}
+	return string(kvV2Data.Data), nil
+}
+
+// getToken reads the file on each call, agents rotate the token in place.
+func (provider *vaultProvider) getToken() (string, error) {
+	settings := provider.settings
+
+	if len(settings.TokenFile) > 0 {
+		token, err := os.ReadFile(settings.TokenFile)
+		if err != nil {
+			return "", fmt.Errorf("read vault token file: %w", err)
+		}
+		return strings.TrimSpace(string(token)), nil
+	}
+
+	if len(settings.Token) > 0 {
+		return settings.Token, nil
+	}
+
+	return os.Getenv("VAULT_TOKEN"), nil
+}
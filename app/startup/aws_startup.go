@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"os"
 	"wrench/app"
-	"wrench/app/manifest/application_settings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -15,12 +14,12 @@ import (
 
 func LoadAwsSecrets(fileConfig map[string][]byte) error {
 
-	applicationSetting, err := application_settings.ParseMapToApplicationSetting(fileConfig)
-	if err != nil {
-		return err
+	serviceSetting := parseServiceSettings(fileConfig)
+	if serviceSetting == nil {
+		return nil
 	}
 
-	setting := applicationSetting.Service.Aws
+	setting := serviceSetting.Aws
 	if setting != nil &&
 		setting.AwsSecretSettings != nil &&
 		len(setting.AwsSecretSettings.SecretsName) > 0 {
@@ -85,6 +84,7 @@ func setMapToEnv(secretName string, jsonMap map[string]interface{}) {
 
 		value, ok := v.(string)
 		if ok {
+			app.RegisterSecretValue(value)
 			os.Setenv(name, value)
 		}
 	}
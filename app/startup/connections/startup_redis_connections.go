@@ -4,44 +4,90 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 	"wrench/app"
 	"wrench/app/manifest/connection_settings"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// redisClientCloseDelay covers the requests and the locks (extend/unlock) still using a replaced client
+const redisClientCloseDelay = time.Minute
+
 var redisClients map[string]redis.UniversalClient
+var redisClientsMutex sync.RWMutex
 
 func loadConnectionsRedis(redisSettings []*connection_settings.RedisConnectionSettings) error {
 
-	if len(redisSettings) > 0 && redisClients == nil {
-		redisClients = make(map[string]redis.UniversalClient)
-	}
-
 	if len(redisSettings) > 0 {
 		for _, setting := range redisSettings {
 
-			uClient := redis.NewUniversalClient(&redis.UniversalOptions{
-				Addrs:    setting.Addresses,
-				DB:       setting.Db,
-				Password: setting.Password,
-			})
-
-			if err := uClient.Ping(context.Background()).Err(); err != nil {
-				app.LogError2(fmt.Sprintf("Error to connect to redis | redis connection id %v", setting.Id), err)
+			uClient, err := newRedisClient(setting)
+			if err != nil {
 				return err
-			} else {
-				app.LogInfo(fmt.Sprintf("Connected to redis | redis connection id %v", setting.Id))
 			}
 
-			redisClients[setting.Id] = uClient
+			setRedisClient(setting.Id, uClient)
 		}
 	}
 
 	return nil
 }
 
+// ReloadRedisConnection replaces the client of the connection id, the previous one is closed
+// only after the new one is connected so a failure keeps the current client working. The
+// close is delayed, the requests that already got the previous client finish with it.
+func ReloadRedisConnection(setting *connection_settings.RedisConnectionSettings) error {
+	uClient, err := newRedisClient(setting)
+	if err != nil {
+		return err
+	}
+
+	previous := setRedisClient(setting.Id, uClient)
+	if previous != nil {
+		time.AfterFunc(redisClientCloseDelay, func() {
+			previous.Close()
+		})
+	}
+
+	return nil
+}
+
+func newRedisClient(setting *connection_settings.RedisConnectionSettings) (redis.UniversalClient, error) {
+	uClient := redis.NewUniversalClient(&redis.UniversalOptions{
+		Addrs:    setting.Addresses,
+		DB:       setting.Db,
+		Password: setting.Password,
+	})
+
+	if err := uClient.Ping(context.Background()).Err(); err != nil {
+		app.LogError2(fmt.Sprintf("Error to connect to redis | redis connection id %v", setting.Id), err)
+		uClient.Close()
+		return nil, err
+	}
+
+	app.LogInfo(fmt.Sprintf("Connected to redis | redis connection id %v", setting.Id))
+	return uClient, nil
+}
+
+func setRedisClient(redisConnectionId string, uClient redis.UniversalClient) redis.UniversalClient {
+	redisClientsMutex.Lock()
+	defer redisClientsMutex.Unlock()
+
+	if redisClients == nil {
+		redisClients = make(map[string]redis.UniversalClient)
+	}
+
+	previous := redisClients[redisConnectionId]
+	redisClients[redisConnectionId] = uClient
+	return previous
+}
+
 func GetRedisConnection(redisConnectionId string) (redis.UniversalClient, error) {
+	redisClientsMutex.RLock()
+	defer redisClientsMutex.RUnlock()
+
 	if len(redisConnectionId) == 0 ||
 		len(redisClients) == 0 ||
 		redisClients[redisConnectionId] == nil {
@@ -0,0 +1,140 @@
+package startup
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var dotEnvKeyRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_.]*$`)
+
+type dotEnvEntry struct {
+	Key   string
+	Value string
+	// Expand is false for single quoted values, they are kept literal
+	Expand bool
+}
+
+// parseDotEnv reads KEY=value lines, with optional export prefix, # comments, single quoted literal
+// values and double quoted values supporting escapes. Quoted values can span multiple lines.
+func parseDotEnv(content string) ([]dotEnvEntry, error) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	var entries []dotEnvEntry
+	lineNumber := 0
+
+	for len(content) > 0 {
+		var line string
+		line, content = cutLine(content)
+		lineNumber++
+
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || !dotEnvKeyRegex.MatchString(key) {
+			return nil, fmt.Errorf("line %v: invalid env declaration", lineNumber)
+		}
+
+		value = strings.TrimLeft(value, " \t")
+		entry := dotEnvEntry{Key: key, Expand: true}
+
+		if len(value) > 0 && (value[0] == '"' || value[0] == '\'') {
+			quote := value[0]
+			// the value continues in the next lines until the closing quote
+			quoted := value[1:]
+			for findClosingQuote(quoted, quote) < 0 && len(content) > 0 {
+				var nextLine string
+				nextLine, content = cutLine(content)
+				lineNumber++
+				quoted += "\n" + nextLine
+			}
+
+			end := findClosingQuote(quoted, quote)
+			if end < 0 {
+				return nil, fmt.Errorf("line %v: env %v has an unclosed quote", lineNumber, key)
+			}
+
+			rest := strings.TrimSpace(quoted[end+1:])
+			if len(rest) > 0 && rest[0] != '#' {
+				return nil, fmt.Errorf("line %v: env %v has content after the closing quote", lineNumber, key)
+			}
+
+			if quote == '\'' {
+				entry.Value = quoted[:end]
+				entry.Expand = false
+			} else {
+				entry.Value = unescapeDoubleQuoted(quoted[:end])
+			}
+		} else {
+			// an inline comment needs a space before the #, values like urls#fragment are kept
+			if index := strings.Index(value, " #"); index >= 0 {
+				value = value[:index]
+			}
+			if index := strings.Index(value, "\t#"); index >= 0 {
+				value = value[:index]
+			}
+			entry.Value = strings.TrimSpace(value)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func cutLine(content string) (string, string) {
+	line, rest, _ := strings.Cut(content, "\n")
+	return line, rest
+}
+
+func findClosingQuote(value string, quote byte) int {
+	for i := 0; i < len(value); i++ {
+		if quote == '"' && value[i] == '\\' {
+			i++
+			continue
+		}
+		if value[i] == quote {
+			return i
+		}
+	}
+	return -1
+}
+
+func unescapeDoubleQuoted(value string) string {
+	var builder strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 == len(value) {
+			builder.WriteByte(value[i])
+			continue
+		}
+
+		i++
+		switch value[i] {
+		case 'n':
+			builder.WriteByte('\n')
+		case 'r':
+			builder.WriteByte('\r')
+		case 't':
+			builder.WriteByte('\t')
+		case '"', '\\':
+			builder.WriteByte(value[i])
+		case '$':
+			// \$ keeps the $ literal, ${ is written escaped to skip the interpolation
+			if i+1 < len(value) && value[i+1] == '{' {
+				builder.WriteString("$$")
+			} else {
+				builder.WriteByte('$')
+			}
+		default:
+			builder.WriteByte('\\')
+			builder.WriteByte(value[i])
+		}
+	}
+	return builder.String()
+}
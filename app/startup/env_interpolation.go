@@ -0,0 +1,125 @@
+package startup
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var envNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*`)
+
+type envLookup func(key string) (string, bool)
+
+// interpolateEnvs replaces ${VAR}, ${VAR:-default}, ${VAR-default}, ${VAR:?message} and ${VAR?message},
+// with the colon an empty value is handled as unset. $${ escapes a literal ${. The legacy {{VAR}} is
+// replaced only when the env is set, so it doesn't collide with the runtime expressions.
+func interpolateEnvs(value string, lookup envLookup) (string, []error) {
+	var builder strings.Builder
+	var errs []error
+
+	for i := 0; i < len(value); {
+		switch {
+		case strings.HasPrefix(value[i:], "$${"):
+			builder.WriteString("${")
+			i += 3
+
+		case strings.HasPrefix(value[i:], "${"):
+			end := findEnvExpressionEnd(value, i+2)
+			if end < 0 {
+				errs = append(errs, fmt.Errorf("env expression %v isn't closed", value[i:]))
+				builder.WriteString(value[i:])
+				i = len(value)
+				continue
+			}
+
+			resolved, exprErrs := resolveEnvExpression(value[i+2:end], lookup)
+			errs = append(errs, exprErrs...)
+			builder.WriteString(resolved)
+			i = end + 1
+
+		case strings.HasPrefix(value[i:], "{{"):
+			end := strings.Index(value[i+2:], "}}")
+			if end < 0 {
+				builder.WriteString(value[i:])
+				i = len(value)
+				continue
+			}
+
+			key := value[i+2 : i+2+end]
+			if envValue, ok := lookup(key); ok && len(key) > 0 {
+				builder.WriteString(envValue)
+			} else {
+				builder.WriteString(value[i : i+4+end])
+			}
+			i += 4 + end
+
+		default:
+			builder.WriteByte(value[i])
+			i++
+		}
+	}
+
+	return builder.String(), errs
+}
+
+// findEnvExpressionEnd returns the index of the } closing the expression, defaults can have nested ${}
+func findEnvExpressionEnd(value string, start int) int {
+	depth := 0
+	for i := start; i < len(value); i++ {
+		switch {
+		case strings.HasPrefix(value[i:], "${"):
+			depth++
+			i++
+		case value[i] == '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+func resolveEnvExpression(expression string, lookup envLookup) (string, []error) {
+	name := envNameRegex.FindString(expression)
+	if len(name) == 0 {
+		return "", []error{fmt.Errorf("env expression ${%v} is invalid", expression)}
+	}
+
+	envValue, isSet := lookup(name)
+	operation := expression[len(name):]
+
+	if len(operation) == 0 {
+		return envValue, nil
+	}
+
+	emptyAsUnset := strings.HasPrefix(operation, ":")
+	if emptyAsUnset {
+		operation = operation[1:]
+		isSet = isSet && len(envValue) > 0
+	}
+
+	if len(operation) == 0 {
+		return "", []error{fmt.Errorf("env expression ${%v} is invalid", expression)}
+	}
+
+	argument := operation[1:]
+	switch operation[0] {
+	case '-':
+		if isSet {
+			return envValue, nil
+		}
+		return interpolateEnvs(argument, lookup)
+
+	case '?':
+		if isSet {
+			return envValue, nil
+		}
+		if len(argument) == 0 {
+			return "", []error{fmt.Errorf("env %v is required", name)}
+		}
+		return "", []error{fmt.Errorf("env %v is required: %v", name, argument)}
+	}
+
+	return "", []error{fmt.Errorf("env expression ${%v} is invalid", expression)}
+}
@@ -0,0 +1,119 @@
+package startup
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInterpolateEnvs(t *testing.T) {
+	envs := map[string]string{
+		"HOST":  "localhost",
+		"EMPTY": "",
+		"DSN":   "user=app password=a=b",
+	}
+	lookup := func(key string) (string, bool) {
+		value, ok := envs[key]
+		return value, ok
+	}
+
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"set", "${HOST}", "localhost"},
+		{"value containing =", "${DSN}", "user=app password=a=b"},
+		{"not set is empty", "[${MISSING}]", "[]"},
+		{"default when not set", "${MISSING:-8080}", "8080"},
+		{"default when empty with colon", "${EMPTY:-fallback}", "fallback"},
+		{"empty kept without colon", "[${EMPTY-fallback}]", "[]"},
+		{"default ignored when set", "${HOST:-other}", "localhost"},
+		{"nested default", "${MISSING:-${HOST}}", "localhost"},
+		{"nested default not set", "${MISSING:-${OTHER:-last}}", "last"},
+		{"default with spaces and colons", "${MISSING:-a b: c}", "a b: c"},
+		{"escaped", "$${HOST}", "${HOST}"},
+		{"escaped next to resolved", "$${HOST}-${HOST}", "${HOST}-localhost"},
+		{"$ alone", "price $10 $HOST", "price $10 $HOST"},
+		{"legacy", "{{HOST}}", "localhost"},
+		{"legacy next to runtime expression", "{{HOST}}/{{bodyContext.x}}", "localhost/{{bodyContext.x}}"},
+		{"legacy not set kept", "{{MISSING}}", "{{MISSING}}"},
+		{"secret ref kept", "{{secret:vault:app/db#password}}", "{{secret:vault:app/db#password}}"},
+		{"embedded", "redis://${HOST}:${PORT:-6379}/0", "redis://localhost:6379/0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, errs := interpolateEnvs(test.value, lookup)
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+
+			if result != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestInterpolateEnvsErrors(t *testing.T) {
+	lookup := func(key string) (string, bool) {
+		if key == "EMPTY" {
+			return "", true
+		}
+		return "", false
+	}
+
+	tests := []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{"required with message", "${DB_HOST:?set the database host}", []string{"env DB_HOST is required: set the database host"}},
+		{"required without message", "${DB_HOST?}", []string{"env DB_HOST is required"}},
+		{"required empty with colon", "${EMPTY:?can't be empty}", []string{"env EMPTY is required: can't be empty"}},
+		{"every required is reported", "${A:?a} ${B:?b} ${C:-ok}", []string{"env A is required: a", "env B is required: b"}},
+		{"required in a default", "${A:-${B:?b}}", []string{"env B is required: b"}},
+		{"invalid name", "${1A}", []string{"env expression ${1A} is invalid"}},
+		{"invalid operation", "${A:x}", []string{"env expression ${A:x} is invalid"}},
+		{"not closed", "${A", []string{"env expression ${A isn't closed"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, errs := interpolateEnvs(test.value, lookup)
+			if len(errs) != len(test.expected) {
+				t.Fatalf("expected %v, got %v", test.expected, errs)
+			}
+
+			for i, err := range errs {
+				if err.Error() != test.expected[i] {
+					t.Errorf("expected %q, got %q", test.expected[i], err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestEnvInterpolationAggregatesRequiredEnvs(t *testing.T) {
+	t.Setenv("WRENCH_TEST_SET", "value")
+
+	files := map[string][]byte{
+		"a.yaml": []byte("a: ${WRENCH_TEST_REQUIRED_1:?first}\nb: ${WRENCH_TEST_REQUIRED_1:?first}\nc: ${WRENCH_TEST_SET}\n"),
+		"b.yaml": []byte("d: ${WRENCH_TEST_REQUIRED_2:?second}\n"),
+	}
+
+	result, err := EnvInterpolation(files)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	for _, expected := range []string{"a.yaml: env WRENCH_TEST_REQUIRED_1 is required: first", "b.yaml: env WRENCH_TEST_REQUIRED_2 is required: second"} {
+		if strings.Count(err.Error(), expected) != 1 {
+			t.Errorf("error should report %q once: %v", expected, err)
+		}
+	}
+
+	if !strings.Contains(string(result["a.yaml"]), "c: value") {
+		t.Errorf("set envs should be resolved: %s", result["a.yaml"])
+	}
+}
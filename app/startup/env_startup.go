@@ -1,12 +1,10 @@
 package startup
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"os"
-	"strings"
 	"wrench/app"
 )
 
@@ -25,68 +23,59 @@ func LoadEnvsFiles() {
 	setEnvFileToSystemEnv(envPathEnvironment)
 }
 
-func EnvInterpolation(values map[string][]byte) map[string][]byte {
+// EnvInterpolation replaces the envs of the config files, every required env not set is reported
+// in the same error.
+func EnvInterpolation(values map[string][]byte) (map[string][]byte, error) {
 	result := make(map[string][]byte)
+	var errs []error
+	errorsFound := make(map[string]bool)
 
 	for i, value := range values {
-		valueString := string(value)
-
-		var envs = os.Environ()
-		for _, env := range envs {
-			envArray := strings.Split(env, "=")
-			envKey := envArray[0]
-			envValue := envArray[1]
-
-			toReplace := fmt.Sprintf("{{%s}}", envKey)
-			if toReplace != "{{}}" {
-				valueString = strings.ReplaceAll(valueString, toReplace, envValue)
+		valueString, valueErrs := interpolateEnvs(string(value), os.LookupEnv)
+		for _, err := range valueErrs {
+			// the same env can be used many times, it's reported once
+			if !errorsFound[err.Error()] {
+				errorsFound[err.Error()] = true
+				errs = append(errs, fmt.Errorf("%v: %w", i, err))
 			}
-
-			result[i] = []byte(valueString)
 		}
+
+		result[i] = []byte(valueString)
 	}
 
-	return result
+	return result, errors.Join(errs...)
 }
 
 func setEnvFileToSystemEnv(pathEnvFile string) {
-	file, err := os.Open(pathEnvFile)
+	content, err := os.ReadFile(pathEnvFile)
 
 	if err != nil {
 		if os.IsNotExist(err) {
 			app.LogInfo(fmt.Sprintf("Env file %s not found ", pathEnvFile))
-			return
 		} else {
 			app.LogError2(err.Error(), err)
 		}
+		return
 	}
 
-	defer file.Close()
-	r := bufio.NewReader(file)
-
 	log.Printf("Loading file %s", pathEnvFile)
-	for {
-		line, _, err := r.ReadLine()
-		if err != nil && fmt.Sprint(err) != "EOF" {
-			log.Fatal(err)
-		}
-
-		if len(line) > 0 {
-			lineText := string(line)
-			if lineText[0] != '#' {
-				arrayLineText := strings.Split(lineText, "=")
-				envKey := arrayLineText[0]
-				envValue := arrayLineText[1]
+	entries, err := parseDotEnv(string(content))
+	if err != nil {
+		log.Fatalf("Env file %s: %v", pathEnvFile, err)
+	}
 
-				if !strings.ContainsAny(envKey, " ") {
-					os.Setenv(envKey, envValue)
-				}
+	for _, entry := range entries {
+		envValue := entry.Value
+		if entry.Expand {
+			// the envs declared before in the file can be used, e.g. URL=http://${HOST}
+			var envErrs []error
+			envValue, envErrs = interpolateEnvs(envValue, os.LookupEnv)
+			if len(envErrs) > 0 {
+				log.Fatalf("Env file %s env %s: %v", pathEnvFile, entry.Key, errors.Join(envErrs...))
 			}
 		}
 
-		if err == io.EOF {
-			break
-		}
+		os.Setenv(entry.Key, envValue)
 	}
 	app.LogInfo(fmt.Sprintf("Done file %s", pathEnvFile))
 }
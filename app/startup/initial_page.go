@@ -37,10 +37,6 @@ func (page *InitialPage) HealthCheckEndpoint(w http.ResponseWriter, r *http.Requ
 
 	var errors []error
 
-	if token_credentials.CredentialErrors != nil {
-		errors = append(errors, token_credentials.CredentialErrors...)
-	}
-
 	if connections.ErrorLoadConnections != nil {
 		errors = append(errors, connections.ErrorLoadConnections...)
 	}
@@ -69,6 +65,16 @@ func (page *InitialPage) HealthCheckEndpoint(w http.ResponseWriter, r *http.Requ
 		}
 	}
 
+	body := bodyHcResult
+	// token credentials change at runtime, they are reported but don't turn the instance unhealthy
+	if tokenCredentialsStatus := token_credentials.GetTokenCredentialsStatus(); tokenCredentialsStatus != nil {
+		body = make(map[string]interface{})
+		for key, value := range bodyHcResult {
+			body[key] = value
+		}
+		body["tokenCredentials"] = tokenCredentialsStatus
+	}
+
 	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(bodyHcResult)
+	json.NewEncoder(w).Encode(body)
 }
@@ -0,0 +1,18 @@
+package startup
+
+import (
+	"encoding/json"
+	"net/http"
+	keys_load "wrench/app/startup/keys"
+)
+
+const jwksRoute = "/.well-known/jwks.json"
+
+func JwksEndpoint(w http.ResponseWriter, r *http.Request) {
+	jwks := keys_load.GetJwks()
+
+	// short enough for a rotation to be seen by the partners in minutes
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jwks)
+}
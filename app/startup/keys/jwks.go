@@ -0,0 +1,106 @@
+package keys_load
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"wrench/app"
+	"wrench/app/manifest/application_settings"
+)
+
+type Jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type Jwks struct {
+	Keys []Jwk `json:"keys"`
+}
+
+func HasJwks() bool {
+	for _, key := range application_settings.ApplicationSettingsStatic.Keys {
+		if key.PublishJwks {
+			return true
+		}
+	}
+	return false
+}
+
+// GetJwks is built on each call, it reflects the keys loaded now. A key that fails to load is logged
+// and left out, during a rotation the partners keep the other keys.
+func GetJwks() *Jwks {
+	jwks := &Jwks{Keys: []Jwk{}}
+
+	for _, key := range application_settings.ApplicationSettingsStatic.Keys {
+		if !key.PublishJwks {
+			continue
+		}
+
+		publicKey, err := GetPublicKey(key.Id)
+		if err != nil {
+			app.LogError2(fmt.Sprintf("key %v isn't published in the jwks", key.Id), err)
+			continue
+		}
+
+		jwk, err := toJwk(publicKey)
+		if err != nil {
+			app.LogError2(fmt.Sprintf("key %v isn't published in the jwks", key.Id), err)
+			continue
+		}
+		jwk.Kid = key.GetKid()
+		jwk.Use = key.GetUse()
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
+
+// GetKid returns the kid of keyId, empty when the key isn't configured.
+func GetKid(keyId string) string {
+	for _, key := range application_settings.ApplicationSettingsStatic.Keys {
+		if key.Id == keyId {
+			return key.GetKid()
+		}
+	}
+	return ""
+}
+
+func toJwk(publicKey crypto.PublicKey) (Jwk, error) {
+	switch typedKey := publicKey.(type) {
+	case *rsa.PublicKey:
+		return Jwk{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(typedKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(typedKey.E)).Bytes()),
+		}, nil
+
+	case *ecdsa.PublicKey:
+		size := (typedKey.Curve.Params().BitSize + 7) / 8
+		return Jwk{
+			Kty: "EC",
+			Crv: typedKey.Curve.Params().Name,
+			X:   base64.RawURLEncoding.EncodeToString(typedKey.X.FillBytes(make([]byte, size))),
+			Y:   base64.RawURLEncoding.EncodeToString(typedKey.Y.FillBytes(make([]byte, size))),
+		}, nil
+
+	case ed25519.PublicKey:
+		return Jwk{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(typedKey),
+		}, nil
+	}
+
+	return Jwk{}, fmt.Errorf("key type %T can't be published", publicKey)
+}
@@ -1,15 +1,26 @@
 package keys_load
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/pem"
 	"fmt"
+	"os"
+	"sync"
 	"wrench/app"
 	"wrench/app/manifest/application_settings"
+	"wrench/app/manifest/key_settings"
 )
 
-var privateKeys map[string]*rsa.PrivateKey
+var privateKeys map[string]crypto.Signer
+var publicKeys map[string]crypto.PublicKey
+var secretKeys map[string][]byte
+var keysMutex sync.RWMutex
 var ErrorLoadKeys []error
 
 func LoadKeys() {
@@ -20,11 +31,20 @@ func LoadKeys() {
 	}
 
 	for _, key := range settings.Keys {
-		_, err := LoadEncryptedPrivateKey(key.Id, key.PrivateRsaKeyDERBase64)
-		addIfErrorKey(err)
+		addIfErrorKey(ReloadKey(key))
 	}
 }
 
+// ReloadKey loads the key again replacing the current one, it's used when the secret of the key rotates.
+func ReloadKey(key *key_settings.KeySettings) error {
+	if key.IsSecret() {
+		return LoadSecretKey(key.Id, key.SecretKeyBase64)
+	}
+
+	_, err := LoadKey(key)
+	return err
+}
+
 func addIfErrorKey(err error) {
 	if err != nil {
 		app.LogError2("Error connections: %v", err)
@@ -32,12 +52,78 @@ func addIfErrorKey(err error) {
 	}
 }
 
-func LoadEncryptedPrivateKey(keyId, privateRsakeyDERBase64 string) (*rsa.PrivateKey, error) {
+func LoadKey(setting *key_settings.KeySettings) (crypto.PublicKey, error) {
+	if len(setting.PrivateRsaKeyDERBase64) > 0 {
+		key, err := LoadEncryptedPrivateKey(setting.Id, setting.PrivateRsaKeyDERBase64)
+		if err != nil {
+			return nil, err
+		}
+		return key.Public(), nil
+	}
 
-	if privateKeys == nil {
-		privateKeys = make(map[string]*rsa.PrivateKey)
+	if setting.IsPublicOnly() {
+		pemContent, err := readPem(setting.Id, setting.PublicKeyPem, setting.PublicKeyPemFile, setting.PublicKeyPemEnv)
+		if err != nil {
+			return nil, err
+		}
+		return LoadPemPublicKey(setting.Id, pemContent)
+	}
+
+	pemContent, err := readPem(setting.Id, setting.PrivateKeyPem, setting.PrivateKeyPemFile, setting.PrivateKeyPemEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := LoadPemPrivateKey(setting.Id, pemContent)
+	if err != nil {
+		return nil, err
+	}
+	return key.Public(), nil
+}
+
+func LoadSecretKey(keyId string, secretKeyBase64 string) error {
+	secretKey, err := base64.StdEncoding.DecodeString(secretKeyBase64)
+	if err != nil {
+		return fmt.Errorf("key %v read secret: %w", keyId, err)
+	}
+
+	if len(secretKey) != 32 {
+		return fmt.Errorf("key %v secret should have 32 bytes for AES-256", keyId)
+	}
+
+	keysMutex.Lock()
+	defer keysMutex.Unlock()
+
+	if secretKeys == nil {
+		secretKeys = make(map[string][]byte)
+	}
+	secretKeys[keyId] = secretKey
+
+	return nil
+}
+
+func readPem(keyId string, inline string, file string, env string) (string, error) {
+	if len(file) > 0 {
+		fileContent, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("key %v read file: %w", keyId, err)
+		}
+		return string(fileContent), nil
+	}
+
+	if len(env) > 0 {
+		pemContent := os.Getenv(env)
+		if len(pemContent) == 0 {
+			return "", fmt.Errorf("key %v env %v is empty", keyId, env)
+		}
+		return pemContent, nil
 	}
 
+	return inline, nil
+}
+
+func LoadEncryptedPrivateKey(keyId, privateRsakeyDERBase64 string) (*rsa.PrivateKey, error) {
+
 	derBytes, err := base64.StdEncoding.DecodeString(privateRsakeyDERBase64)
 	if err != nil {
 		return nil, fmt.Errorf("read key: %w", err)
@@ -53,15 +139,162 @@ func LoadEncryptedPrivateKey(keyId, privateRsakeyDERBase64 string) (*rsa.Private
 		return nil, fmt.Errorf("not RSA")
 	}
 
-	privateKeys[keyId] = rsaKey
+	setPrivateKey(keyId, rsaKey)
 
 	return rsaKey, nil
 }
 
-func GetPrivateKey(keyId string) (*rsa.PrivateKey, error) {
+func LoadPemPrivateKey(keyId string, pemContent string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(pemContent))
+	if block == nil {
+		return nil, fmt.Errorf("key %v: no PEM block found", keyId)
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("key %v: PEM type %v not supported", keyId, block.Type)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("key %v parse: %w", keyId, err)
+	}
+
+	signer, err := toSigner(key)
+	if err != nil {
+		return nil, fmt.Errorf("key %v: %w", keyId, err)
+	}
+
+	setPrivateKey(keyId, signer)
+
+	return signer, nil
+}
+
+func LoadPemPublicKey(keyId string, pemContent string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(pemContent))
+	if block == nil {
+		return nil, fmt.Errorf("key %v: no PEM block found", keyId)
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var certificate *x509.Certificate
+		if certificate, err = x509.ParseCertificate(block.Bytes); err == nil {
+			key = certificate.PublicKey
+		}
+	default:
+		return nil, fmt.Errorf("key %v: PEM type %v not supported", keyId, block.Type)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("key %v parse: %w", keyId, err)
+	}
+
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+	default:
+		return nil, fmt.Errorf("key %v: key type %T not supported", keyId, key)
+	}
+
+	setPublicKey(keyId, key)
+
+	return key, nil
+}
+
+func toSigner(key any) (crypto.Signer, error) {
+	switch typedKey := key.(type) {
+	case *rsa.PrivateKey:
+		return typedKey, nil
+	case *ecdsa.PrivateKey:
+		if typedKey.Curve != elliptic.P256() && typedKey.Curve != elliptic.P384() {
+			return nil, fmt.Errorf("EC curve %v not supported, use P-256 or P-384", typedKey.Curve.Params().Name)
+		}
+		return typedKey, nil
+	case ed25519.PrivateKey:
+		return typedKey, nil
+	}
+
+	return nil, fmt.Errorf("key type %T not supported", key)
+}
+
+func setPrivateKey(keyId string, signer crypto.Signer) {
+	keysMutex.Lock()
+	defer keysMutex.Unlock()
+
+	if privateKeys == nil {
+		privateKeys = make(map[string]crypto.Signer)
+	}
+	if publicKeys == nil {
+		publicKeys = make(map[string]crypto.PublicKey)
+	}
+
+	privateKeys[keyId] = signer
+	publicKeys[keyId] = signer.Public()
+}
+
+func setPublicKey(keyId string, publicKey crypto.PublicKey) {
+	keysMutex.Lock()
+	defer keysMutex.Unlock()
+
+	if publicKeys == nil {
+		publicKeys = make(map[string]crypto.PublicKey)
+	}
+
+	publicKeys[keyId] = publicKey
+}
+
+func GetPublicKey(keyId string) (crypto.PublicKey, error) {
+	keysMutex.RLock()
+	key, ok := publicKeys[keyId]
+	keysMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("key not found: %s", keyId)
+	}
+	return key, nil
+}
+
+func GetSigner(keyId string) (crypto.Signer, error) {
+	keysMutex.RLock()
 	key, ok := privateKeys[keyId]
+	keysMutex.RUnlock()
 	if !ok {
 		return nil, fmt.Errorf("key not found: %s", keyId)
 	}
 	return key, nil
 }
+
+func GetPrivateKey(keyId string) (*rsa.PrivateKey, error) {
+	key, err := GetSigner(keyId)
+	if err != nil {
+		return nil, err
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("key %s is not RSA", keyId)
+	}
+	return rsaKey, nil
+}
+
+func GetSecretKey(keyId string) ([]byte, error) {
+	keysMutex.RLock()
+	key, ok := secretKeys[keyId]
+	keysMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("secret key not found: %s", keyId)
+	}
+	return key, nil
+}
@@ -0,0 +1,141 @@
+package startup
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+	"wrench/app"
+	"wrench/app/auth"
+	"wrench/app/cross_funcs"
+	"wrench/app/manifest"
+	"wrench/app/manifest/api_settings"
+	"wrench/app/manifest/application_settings"
+	"wrench/app/secrets"
+	"wrench/app/startup/connections"
+	keys_load "wrench/app/startup/keys"
+	"wrench/app/startup/token_credentials"
+	"wrench/app/stores"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// RefreshSecrets polls the secrets on service.secrets.refreshIntervalInSeconds. fileConfig is the config
+// after the env interpolation, when a secret rotates it's resolved again and only the redis connections,
+// token credentials, hmac authorizations and keys whose settings changed are reloaded.
+func RefreshSecrets(ctx context.Context, fileConfig map[string][]byte, settings *application_settings.ApplicationSettings) {
+	if settings == nil || settings.Service == nil || !secrets.HasSecretRefs(fileConfig) {
+		return
+	}
+
+	interval := settings.Service.Secrets.GetRefreshInterval()
+	if interval <= 0 {
+		return
+	}
+
+	applied := settings
+	pending := false
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		rotated, resolved, err := secrets.RefreshSecretRefs(ctx, fileConfig)
+		if err != nil {
+			app.LogError2(fmt.Sprintf("Error refreshing secrets: %v", err), err)
+			continue
+		}
+
+		for _, secret := range rotated {
+			app.LogInfo(fmt.Sprintf("Secret %v rotated", secret))
+			app.SecretRotationCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("secret", secret)))
+		}
+
+		if len(rotated) == 0 && !pending {
+			continue
+		}
+
+		refreshed, err := application_settings.ParseMapToApplicationSetting(resolved)
+		if err != nil {
+			app.LogError2(fmt.Sprintf("Error parse yaml with the rotated secrets: %v", err), err)
+			continue
+		}
+
+		// a failed reload keeps the applied settings so it's tried again on the next poll
+		pending = !reloadRotatedSettings(applied, refreshed)
+		if !pending {
+			applied = refreshed
+		}
+	}
+}
+
+func reloadRotatedSettings(previous *application_settings.ApplicationSettings, current *application_settings.ApplicationSettings) bool {
+	reloaded := true
+
+	if previous.Connections != nil && current.Connections != nil {
+		for _, setting := range changedSettings(previous.Connections.Redis, current.Connections.Redis) {
+			if err := connections.ReloadRedisConnection(setting); err != nil {
+				reloaded = false
+				continue
+			}
+
+			stores.ResetRedisStores(setting.Id)
+			cross_funcs.ResetRedsyncInstance(setting.Id)
+			app.LogInfo(fmt.Sprintf("Redis connection %v reloaded", setting.Id))
+		}
+	}
+
+	for _, setting := range changedSettings(previous.TokenCredentials, current.TokenCredentials) {
+		token_credentials.ReloadTokenCredential(setting)
+		app.LogInfo(fmt.Sprintf("Token credential %v reloaded", setting.Id))
+	}
+
+	for _, setting := range changedSettings(previous.Keys, current.Keys) {
+		if err := keys_load.ReloadKey(setting); err != nil {
+			app.LogError2(fmt.Sprintf("Error reloading key %v: %v", setting.Id, err), err)
+			reloaded = false
+			continue
+		}
+		app.LogInfo(fmt.Sprintf("Key %v reloaded", setting.Id))
+	}
+
+	if previous.Api != nil && current.Api != nil {
+		previousHmacs := getHmacAuthorizations(previous.Api)
+		for _, setting := range getHmacAuthorizations(current.Api) {
+			if previousSetting, ok := previousHmacs[setting.Id]; ok && previousSetting.Key != setting.Key {
+				auth.SetHmacKey(setting.Id, setting.Key)
+				app.LogInfo(fmt.Sprintf("Hmac authorization %v key reloaded", setting.Id))
+			}
+		}
+	}
+
+	return reloaded
+}
+
+// changedSettings returns the current settings that exist in previous with a different value,
+// new ids aren't reloaded because nothing was initialized for them on startup.
+func changedSettings[T manifest.HasId](previous []T, current []T) []T {
+	previousById := make(map[string]T)
+	for _, setting := range previous {
+		previousById[setting.GetId()] = setting
+	}
+
+	var changed []T
+	for _, setting := range current {
+		if previousSetting, ok := previousById[setting.GetId()]; ok && !reflect.DeepEqual(previousSetting, setting) {
+			changed = append(changed, setting)
+		}
+	}
+	return changed
+}
+
+func getHmacAuthorizations(apiSettings *api_settings.ApiSettings) map[string]*api_settings.AuthorizationSettings {
+	hmacs := make(map[string]*api_settings.AuthorizationSettings)
+	for _, setting := range apiSettings.GetAllAuthorizations() {
+		if setting.Type == api_settings.HMACAuthorizationType {
+			hmacs[setting.Id] = setting
+		}
+	}
+	return hmacs
+}
@@ -0,0 +1,30 @@
+package startup
+
+import (
+	"context"
+	"wrench/app/manifest/service_settings"
+	"wrench/app/secrets"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadSecretRefs replaces the {{secret:provider:path#field}} refs, it runs after the env
+// interpolation so the providers settings can use envs.
+func LoadSecretRefs(ctx context.Context, fileConfig map[string][]byte) (map[string][]byte, error) {
+	secrets.LoadProviders(parseServiceSettings(fileConfig))
+	return secrets.ResolveSecretRefs(ctx, fileConfig)
+}
+
+// parseServiceSettings reads only the service, the rest of the config may still have refs to resolve.
+func parseServiceSettings(fileConfig map[string][]byte) *service_settings.ServiceSettings {
+	for _, data := range fileConfig {
+		var config struct {
+			Service *service_settings.ServiceSettings `yaml:"service"`
+		}
+
+		if err := yaml.Unmarshal(data, &config); err == nil && config.Service != nil {
+			return config.Service
+		}
+	}
+	return nil
+}
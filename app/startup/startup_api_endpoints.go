@@ -8,6 +8,7 @@ import (
 
 	handler "wrench/app/handlers"
 	"wrench/app/manifest/application_settings"
+	keys_load "wrench/app/startup/keys"
 
 	rootApp "wrench/app"
 
@@ -58,6 +59,10 @@ func LoadApiEndpoint(ctx context.Context) http.Handler {
 	muxRoute.HandleFunc("/", initialPage.WriteInitialPage).Methods("GET")
 	muxRoute.HandleFunc("/hc", initialPage.HealthCheckEndpoint).Methods("GET")
 
+	if keys_load.HasJwks() {
+		muxRoute.HandleFunc(jwksRoute, JwksEndpoint).Methods("GET")
+	}
+
 	if app.Api.Cors != nil {
 		if len(app.Api.Cors.Origins) == 0 {
 			app.Api.Cors.Origins = []string{"*"}
@@ -0,0 +1,37 @@
+package startup
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"wrench/app/manifest/api_settings"
+)
+
+func LoadTlsConfig(tlsSettings *api_settings.TlsSettings) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	switch tlsSettings.GetClientAuth() {
+	case api_settings.TlsClientAuthOptional:
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	case api_settings.TlsClientAuthRequired:
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		tlsConfig.ClientAuth = tls.NoClientCert
+		return tlsConfig, nil
+	}
+
+	caBundle, err := os.ReadFile(tlsSettings.ClientCaFile)
+	if err != nil {
+		return nil, fmt.Errorf("error to read api.tls.clientCaFile %v: %w", tlsSettings.ClientCaFile, err)
+	}
+
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caBundle) {
+		return nil, errors.New("api.tls.clientCaFile doesn't contain any PEM certificate")
+	}
+	tlsConfig.ClientCAs = clientCAs
+
+	return tlsConfig, nil
+}
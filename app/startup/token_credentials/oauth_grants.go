@@ -0,0 +1,222 @@
+package token_credentials
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"wrench/app/auth"
+	client "wrench/app/clients/http"
+	credential "wrench/app/manifest/token_credential_settings"
+	keys_load "wrench/app/startup/keys"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+const clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+const jwtBearerGrantType = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+const defaultAssertionLifetime = 5 * time.Minute
+
+func supportsRefreshToken(tokenCredentialType credential.TokenCredentialType) bool {
+	return tokenCredentialType != credential.TokenCredentialBasicCredential &&
+		tokenCredentialType != credential.TokenCredentialCustomAuthentication
+}
+
+func privateKeyJwtCredentials(ctx context.Context, setting *credential.TokenCredentialSetting) (*auth.TokenData, error) {
+	data := url.Values{}
+	data.Set("grant_type", "client_credentials")
+
+	if len(strings.TrimSpace(setting.PrivateKeyJwt.Scope)) > 0 {
+		data.Set("scope", setting.PrivateKeyJwt.Scope)
+	}
+
+	if err := setClientAuthentication(data, setting); err != nil {
+		return nil, err
+	}
+
+	return postTokenRequest(ctx, setting, data)
+}
+
+func jwtBearerCredentials(ctx context.Context, setting *credential.TokenCredentialSetting) (*auth.TokenData, error) {
+	jwtBearer := setting.JwtBearer
+
+	claims := jwt.MapClaims{}
+	for name, value := range jwtBearer.Claims {
+		claims[name] = value
+	}
+
+	claims["iss"] = jwtBearer.Issuer
+	claims["aud"] = getAssertionAudience(jwtBearer.Audience, setting)
+	if len(jwtBearer.Subject) > 0 {
+		claims["sub"] = jwtBearer.Subject
+	}
+	if len(jwtBearer.Scope) > 0 {
+		claims["scope"] = jwtBearer.Scope
+	}
+
+	assertion, err := signAssertion(claims, jwtBearer.KeyId, jwtBearer.Kid, jwtBearer.GetAlgorithm(), jwtBearer.AssertionLifetimeSeconds)
+	if err != nil {
+		return nil, err
+	}
+
+	data := url.Values{}
+	data.Set("grant_type", jwtBearerGrantType)
+	data.Set("assertion", assertion)
+
+	if len(strings.TrimSpace(jwtBearer.Scope)) > 0 {
+		data.Set("scope", jwtBearer.Scope)
+	}
+
+	if err := setClientAuthentication(data, setting); err != nil {
+		return nil, err
+	}
+
+	return postTokenRequest(ctx, setting, data)
+}
+
+func passwordCredentials(ctx context.Context, setting *credential.TokenCredentialSetting) (*auth.TokenData, error) {
+	data := url.Values{}
+	data.Set("grant_type", "password")
+	data.Set("username", setting.Password.Username)
+	data.Set("password", setting.Password.Password)
+
+	if len(strings.TrimSpace(setting.Password.Scope)) > 0 {
+		data.Set("scope", setting.Password.Scope)
+	}
+
+	if err := setClientAuthentication(data, setting); err != nil {
+		return nil, err
+	}
+
+	return postTokenRequest(ctx, setting, data)
+}
+
+func refreshTokenCredentials(ctx context.Context, setting *credential.TokenCredentialSetting, refreshToken string) (*auth.TokenData, error) {
+	data := url.Values{}
+	data.Set("grant_type", "refresh_token")
+	data.Set("refresh_token", refreshToken)
+
+	if setting.RefreshToken != nil && len(strings.TrimSpace(setting.RefreshToken.Scope)) > 0 {
+		data.Set("scope", setting.RefreshToken.Scope)
+	}
+
+	if err := setClientAuthentication(data, setting); err != nil {
+		return nil, err
+	}
+
+	return postTokenRequest(ctx, setting, data)
+}
+
+// setClientAuthentication uses client_secret_post, or private_key_jwt for the private_key_jwt type.
+// The basic type authenticates with the Authorization header set by postTokenRequest.
+func setClientAuthentication(data url.Values, setting *credential.TokenCredentialSetting) error {
+	var clientId, clientSecret string
+
+	switch setting.Type {
+	case credential.TokenCredentialClientCredential:
+		clientId, clientSecret = setting.ClientCredential.ClientId, setting.ClientCredential.ClientSecret
+	case credential.TokenCredentialPassword:
+		clientId, clientSecret = setting.Password.ClientId, setting.Password.ClientSecret
+	case credential.TokenCredentialRefreshToken:
+		clientId, clientSecret = setting.RefreshToken.ClientId, setting.RefreshToken.ClientSecret
+	case credential.TokenCredentialJwtBearer:
+		clientId, clientSecret = setting.JwtBearer.ClientId, setting.JwtBearer.ClientSecret
+	case credential.TokenCredentialPrivateKeyJwt:
+		privateKeyJwt := setting.PrivateKeyJwt
+		claims := jwt.MapClaims{
+			"iss": privateKeyJwt.ClientId,
+			"sub": privateKeyJwt.ClientId,
+			"aud": getAssertionAudience(privateKeyJwt.Audience, setting),
+		}
+
+		assertion, err := signAssertion(claims, privateKeyJwt.KeyId, privateKeyJwt.Kid, privateKeyJwt.GetAlgorithm(), privateKeyJwt.AssertionLifetimeSeconds)
+		if err != nil {
+			return err
+		}
+
+		data.Set("client_id", privateKeyJwt.ClientId)
+		data.Set("client_assertion_type", clientAssertionType)
+		data.Set("client_assertion", assertion)
+		return nil
+	}
+
+	if len(clientId) > 0 {
+		data.Set("client_id", clientId)
+	}
+
+	if len(clientSecret) > 0 {
+		data.Set("client_secret", clientSecret)
+	}
+
+	return nil
+}
+
+func getAssertionAudience(audience string, setting *credential.TokenCredentialSetting) string {
+	if len(audience) == 0 {
+		return setting.AuthEndpoint
+	}
+	return audience
+}
+
+// signAssertion adds iat, exp and a unique jti, servers reject assertions already used.
+func signAssertion(claims jwt.MapClaims, keyId string, kid string, algorithm string, lifetimeSeconds int) (string, error) {
+	privateKey, err := keys_load.GetSigner(keyId)
+	if err != nil {
+		return "", err
+	}
+
+	signingMethod := jwt.GetSigningMethod(algorithm)
+	if signingMethod == nil {
+		return "", fmt.Errorf("assertion algorithm %v not supported", algorithm)
+	}
+
+	lifetime := defaultAssertionLifetime
+	if lifetimeSeconds > 0 {
+		lifetime = time.Duration(lifetimeSeconds) * time.Second
+	}
+
+	now := time.Now()
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(lifetime).Unix()
+	claims["jti"] = uuid.NewString()
+
+	token := jwt.NewWithClaims(signingMethod, claims)
+	if len(kid) > 0 {
+		token.Header["kid"] = kid
+	}
+
+	return token.SignedString(privateKey)
+}
+
+func postTokenRequest(ctx context.Context, setting *credential.TokenCredentialSetting, data url.Values) (*auth.TokenData, error) {
+	request := new(client.HttpClientRequestData)
+	request.Body = []byte(data.Encode())
+	request.Method = "POST"
+	request.Url = setting.AuthEndpoint
+	request.SetHeader("Content-Type", "application/x-www-form-urlencoded")
+
+	if setting.Type == credential.TokenCredentialBasicCredential {
+		basicCredential := fmt.Sprintf("%s:%s", setting.Basic.Username, setting.Basic.Password)
+		request.SetHeader("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(basicCredential)))
+	}
+
+	response, err := client.HttpClientDo(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if !response.StatusCodeSuccess() {
+		return nil, fmt.Errorf("Can't get jwtToken response_status_code: %v settings id %v grant_type %v", response.StatusCode, setting.Id, data.Get("grant_type"))
+	}
+
+	tokenData := new(auth.TokenData)
+	if err := json.Unmarshal(response.Body, &tokenData); err != nil {
+		return nil, err
+	}
+
+	return tokenData, nil
+}
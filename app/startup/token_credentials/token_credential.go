@@ -2,152 +2,369 @@ package token_credentials
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
+	"wrench/app"
 	"wrench/app/auth"
 	client "wrench/app/clients/http"
 	"wrench/app/json_map"
 	"wrench/app/manifest/application_settings"
 	credential "wrench/app/manifest/token_credential_settings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 )
 
-var tokenCredentials map[string]*auth.TokenData
-var CredentialErrors []error
+const refreshLoopInterval = 30 * time.Second
+const fetchRetryMinDelay = time.Second
+const fetchRetryMaxDelay = time.Minute
+
+// tokenCredentialEntry serializes the fetches of one credential, callers waiting on mutex
+// reuse the token fetched by the first one (single flight).
+type tokenCredentialEntry struct {
+	mutex      sync.Mutex
+	stateMutex sync.RWMutex
+	setting    *credential.TokenCredentialSetting
+	tokenData  *auth.TokenData
+	refreshAt  time.Time
+	failures   int
+	lastError  error
+	refreshing bool
+
+	// refreshToken is the last one returned by the server, servers rotating it invalidate the previous
+	refreshToken string
+}
 
-func GetTokenCredentialById(tokenCredentialId string) *auth.TokenData {
-	if tokenCredentials == nil {
-		return nil
+type TokenCredentialStatus struct {
+	Status    string     `json:"status"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	Failures  int        `json:"failures,omitempty"`
+	LastError string     `json:"lastError,omitempty"`
+}
+
+var tokenCredentials = make(map[string]*tokenCredentialEntry)
+var tokenCredentialsMutex sync.RWMutex
+
+func getTokenCredentialEntry(tokenCredentialId string) *tokenCredentialEntry {
+	tokenCredentialsMutex.RLock()
+	entry := tokenCredentials[tokenCredentialId]
+	tokenCredentialsMutex.RUnlock()
+
+	if entry != nil {
+		return entry
+	}
+
+	app_settings := application_settings.ApplicationSettingsStatic
+	for _, setting := range app_settings.TokenCredentials {
+		if setting.Id != tokenCredentialId {
+			continue
+		}
+
+		tokenCredentialsMutex.Lock()
+		defer tokenCredentialsMutex.Unlock()
+
+		if entry = tokenCredentials[tokenCredentialId]; entry == nil {
+			entry = &tokenCredentialEntry{setting: setting}
+			tokenCredentials[tokenCredentialId] = entry
+		}
+		return entry
 	}
 
-	return tokenCredentials[tokenCredentialId]
+	return nil
 }
 
-func LoadTokenCredentialAuthentication() {
-	app_settings := application_settings.ApplicationSettingsStatic
+// ReloadTokenCredential replaces the credential discarding its token, the next use fetches one
+// with the new setting (e.g. a rotated client secret).
+func ReloadTokenCredential(setting *credential.TokenCredentialSetting) {
+	tokenCredentialsMutex.Lock()
+	defer tokenCredentialsMutex.Unlock()
 
-	if len(app_settings.TokenCredentials) > 0 {
-		if tokenCredentials == nil {
-			tokenCredentials = make(map[string]*auth.TokenData)
+	tokenCredentials[setting.Id] = &tokenCredentialEntry{setting: setting}
+}
+
+// GetTokenCredentialById returns a valid token, fetching it on the first use or when it's expired.
+// When the token is close to exp it's returned and refreshed in background.
+func GetTokenCredentialById(ctx context.Context, tokenCredentialId string) (*auth.TokenData, error) {
+	entry := getTokenCredentialEntry(tokenCredentialId)
+	if entry == nil {
+		return nil, fmt.Errorf("tokenCredential %v not found", tokenCredentialId)
+	}
+
+	tokenData, refreshAt := entry.getToken()
+	if tokenData != nil && !tokenData.IsExpired(0) {
+		if time.Now().After(refreshAt) {
+			entry.refreshInBackground()
 		}
+		return tokenData, nil
+	}
 
-		for {
-			for _, setting := range app_settings.TokenCredentials {
-
-				tokenData := GetTokenCredentialById(setting.Id)
-				if tokenData != nil {
-					if !tokenData.IsExpired(5, setting.IsOpaque) {
-						continue
-					}
-				}
-
-				var err error
-				if setting.Type == credential.TokenCredentialClientCredential {
-					tokenData, err = authenticateClientCredentials(setting)
-				} else if setting.Type == credential.TokenCredentialBasicCredential {
-					tokenData, err = basicCredentials(setting)
-				} else if setting.Type == credential.TokenCredentialCustomAuthentication {
-					tokenData, err = customAuthentication(setting)
-				}
-
-				if err != nil {
-					CredentialErrors = append(CredentialErrors, err)
-					continue
-				}
-
-				if !setting.IsOpaque {
-					tokenData.LoadJwtPayload()
-				}
-
-				tokenCredentials[setting.Id] = tokenData
-			}
+	return entry.refresh(ctx, tokenData)
+}
+
+// ForceRefreshTokenCredential is used when an upstream rejects the token (401). Concurrent callers
+// informing the same rejected token share a single refresh.
+func ForceRefreshTokenCredential(ctx context.Context, tokenCredentialId string, rejected *auth.TokenData) (*auth.TokenData, error) {
+	entry := getTokenCredentialEntry(tokenCredentialId)
+	if entry == nil {
+		return nil, fmt.Errorf("tokenCredential %v not found", tokenCredentialId)
+	}
+
+	return entry.refresh(ctx, rejected)
+}
+
+// GetTokenCredentialsStatus is exposed in the health check, the credentials are loaded lazily so notLoaded isn't an error.
+func GetTokenCredentialsStatus() map[string]TokenCredentialStatus {
+	app_settings := application_settings.ApplicationSettingsStatic
+	if len(app_settings.TokenCredentials) == 0 {
+		return nil
+	}
 
-			time.Sleep(2 * time.Minute)
+	status := make(map[string]TokenCredentialStatus)
+	for _, setting := range app_settings.TokenCredentials {
+		entry := getTokenCredentialEntry(setting.Id)
+
+		entry.stateMutex.RLock()
+		credentialStatus := TokenCredentialStatus{Status: "notLoaded", Failures: entry.failures}
+		if entry.tokenData != nil {
+			credentialStatus.Status = "ok"
+			expiresAt := entry.tokenData.ExpiresAt
+			credentialStatus.ExpiresAt = &expiresAt
+		}
+		if entry.lastError != nil {
+			credentialStatus.Status = "failing"
+			credentialStatus.LastError = entry.lastError.Error()
 		}
+		entry.stateMutex.RUnlock()
+
+		status[setting.Id] = credentialStatus
 	}
+
+	return status
 }
 
-func authenticateClientCredentials(setting *credential.TokenCredentialSetting) (*auth.TokenData, error) {
-	request := new(client.HttpClientRequestData)
-	data := url.Values{}
-	data.Set("client_id", setting.ClientCredential.ClientId)
-	data.Set("client_secret", setting.ClientCredential.ClientSecret)
-	data.Set("grant_type", "client_credentials")
+func (entry *tokenCredentialEntry) getToken() (*auth.TokenData, time.Time) {
+	entry.stateMutex.RLock()
+	defer entry.stateMutex.RUnlock()
+	return entry.tokenData, entry.refreshAt
+}
 
-	if len(strings.TrimSpace(setting.ClientCredential.Audiance)) > 0 {
-		data.Set("audience", setting.ClientCredential.Audiance)
+func (entry *tokenCredentialEntry) getLastError() error {
+	entry.stateMutex.RLock()
+	defer entry.stateMutex.RUnlock()
+	return entry.lastError
+}
+
+// refresh fetches a new token unless another caller already replaced the stale one. Getting back
+// the stale token (the fetch failed but it isn't expired) is an error, the caller asked to replace it.
+func (entry *tokenCredentialEntry) refresh(ctx context.Context, stale *auth.TokenData) (*auth.TokenData, error) {
+	entry.mutex.Lock()
+	defer entry.mutex.Unlock()
+
+	tokenData, refreshAt := entry.getToken()
+	if tokenData != nil && tokenData != stale && !tokenData.IsExpired(0) {
+		return tokenData, nil
 	}
 
-	request.Body = []byte(data.Encode())
-	request.Method = "POST"
-	request.Url = setting.AuthEndpoint
+	// after a failure the server is only called again when the backoff elapses
+	if lastError := entry.getLastError(); lastError != nil && time.Now().Before(refreshAt) {
+		return nil, lastError
+	}
 
-	request.SetHeader("Content-Type", "application/x-www-form-urlencoded")
-	ctx := context.Background()
+	var err error
+	if entry.setting.Store != nil {
+		tokenData, err = entry.fetchShared(ctx, stale)
+	} else {
+		tokenData, err = entry.fetch(ctx)
+	}
 
-	response, err := client.HttpClientDo(ctx, request)
+	if err == nil && stale != nil && tokenData.AccessToken == stale.AccessToken {
+		if lastError := entry.getLastError(); lastError != nil {
+			return nil, lastError
+		}
+		return nil, fmt.Errorf("tokenCredential %v token wasn't replaced", entry.setting.Id)
+	}
+
+	return tokenData, err
+}
+
+func (entry *tokenCredentialEntry) refreshInBackground() {
+	entry.stateMutex.Lock()
+	if entry.refreshing {
+		entry.stateMutex.Unlock()
+		return
+	}
+	entry.refreshing = true
+	entry.stateMutex.Unlock()
+
+	go func() {
+		defer func() {
+			entry.stateMutex.Lock()
+			entry.refreshing = false
+			entry.stateMutex.Unlock()
+		}()
+
+		tokenData, _ := entry.getToken()
+		entry.refresh(context.Background(), tokenData)
+	}()
+}
+
+func (entry *tokenCredentialEntry) fetch(ctx context.Context) (*auth.TokenData, error) {
+	setting := entry.setting
+	start := time.Now()
+
+	tokenData, err := entry.grant(ctx)
+	recordTokenCredentialMetric(ctx, setting.Id, time.Since(start), err)
+
+	entry.stateMutex.Lock()
+	defer entry.stateMutex.Unlock()
 
 	if err != nil {
-		// TODO add error flow
+		entry.failures++
+		entry.lastError = err
+		entry.refreshAt = time.Now().Add(min(fetchRetryMinDelay<<min(entry.failures-1, 6), fetchRetryMaxDelay))
+		app.LogError2(fmt.Sprintf("tokenCredential %v error to fetch token", setting.Id), err)
+
+		// a token not expired yet is still better than none
+		if entry.tokenData != nil && !entry.tokenData.IsExpired(0) {
+			return entry.tokenData, nil
+		}
 		return nil, err
-	} else {
+	}
 
-		if response.StatusCodeSuccess() {
-			tokenData := new(auth.TokenData)
-			jsonErr := json.Unmarshal(response.Body, &tokenData)
-			if jsonErr != nil {
-				return nil, jsonErr
-			}
+	if !setting.IsOpaque {
+		tokenData.LoadJwtPayload()
+	}
+	tokenData.LoadExpiresAt(setting.IsOpaque)
+
+	refreshBefore := setting.GetRefreshBeforeExpiry()
+	if lifetime := time.Until(tokenData.ExpiresAt); refreshBefore > lifetime/2 {
+		refreshBefore = lifetime / 2
+	}
+
+	entry.setToken(tokenData, tokenData.ExpiresAt.Add(-refreshBefore), tokenData.RefreshToken)
+	return tokenData, nil
+}
+
+// setToken should be called holding stateMutex.
+func (entry *tokenCredentialEntry) setToken(tokenData *auth.TokenData, refreshAt time.Time, refreshToken string) {
+	entry.tokenData = tokenData
+	entry.refreshAt = refreshAt
+	entry.failures = 0
+	entry.lastError = nil
+	if len(refreshToken) > 0 {
+		entry.refreshToken = refreshToken
+	}
+}
+
+// grant uses the refresh token when the server issued one, falling back to the credential grant when it's rejected.
+func (entry *tokenCredentialEntry) grant(ctx context.Context) (*auth.TokenData, error) {
+	setting := entry.setting
 
+	entry.stateMutex.RLock()
+	refreshToken := entry.refreshToken
+	entry.stateMutex.RUnlock()
+
+	if len(refreshToken) > 0 && supportsRefreshToken(setting.Type) {
+		tokenData, err := refreshTokenCredentials(ctx, setting, refreshToken)
+		if err == nil {
 			return tokenData, nil
 		}
 
-		return nil, fmt.Errorf("Can't get jwtToken response_status_code: %v settings id %v", response.StatusCode, setting.Id)
+		app.LogWarning(fmt.Sprintf("tokenCredential %v refresh token rejected: %v", setting.Id, err))
 	}
+
+	return fetchTokenCredential(ctx, setting)
 }
 
-func basicCredentials(setting *credential.TokenCredentialSetting) (*auth.TokenData, error) {
-	request := new(client.HttpClientRequestData)
-	data := url.Values{}
-	data.Set("grant_type", "client_credentials")
+func fetchTokenCredential(ctx context.Context, setting *credential.TokenCredentialSetting) (*auth.TokenData, error) {
+	switch setting.Type {
+	case credential.TokenCredentialClientCredential:
+		return authenticateClientCredentials(ctx, setting)
+	case credential.TokenCredentialBasicCredential:
+		return basicCredentials(ctx, setting)
+	case credential.TokenCredentialCustomAuthentication:
+		return customAuthentication(ctx, setting)
+	case credential.TokenCredentialPrivateKeyJwt:
+		return privateKeyJwtCredentials(ctx, setting)
+	case credential.TokenCredentialJwtBearer:
+		return jwtBearerCredentials(ctx, setting)
+	case credential.TokenCredentialPassword:
+		return passwordCredentials(ctx, setting)
+	case credential.TokenCredentialRefreshToken:
+		return refreshTokenCredentials(ctx, setting, setting.RefreshToken.RefreshToken)
+	}
 
-	request.Body = []byte(data.Encode())
-	request.Method = "POST"
-	request.Url = setting.AuthEndpoint
+	return nil, fmt.Errorf("tokenCredential %v type %v not supported", setting.Id, setting.Type)
+}
 
-	credential := fmt.Sprintf("%s:%s", setting.Basic.Username, setting.Basic.Password)
-	credentialEncoded := base64.StdEncoding.EncodeToString([]byte(credential))
+func recordTokenCredentialMetric(ctx context.Context, tokenCredentialId string, duration time.Duration, err error) {
+	if app.TokenCredentialDuration == nil {
+		return
+	}
 
-	request.SetHeader("Content-Type", "application/x-www-form-urlencoded")
-	request.SetHeader("Authorization", fmt.Sprintf("Basic %s", credentialEncoded))
+	app.TokenCredentialDuration.Record(ctx, float64(duration.Milliseconds()),
+		metric.WithAttributes(
+			attribute.String("token_credential_id", tokenCredentialId),
+			attribute.Bool("token_credential_success", err == nil),
+			attribute.String("instance", app.GetInstanceID()),
+		),
+	)
+}
 
-	ctx := context.Background()
+// LoadTokenCredentialAuthentication refreshes in background the tokens already in use before they expire,
+// the first fetch of each credential happens on demand.
+func LoadTokenCredentialAuthentication() {
+	app_settings := application_settings.ApplicationSettingsStatic
 
-	response, err := client.HttpClientDo(ctx, request)
+	if len(app_settings.TokenCredentials) == 0 {
+		return
+	}
 
-	if err != nil {
-		// TODO add error flow
-		return nil, err
-	} else {
+	for {
+		time.Sleep(refreshLoopInterval)
 
-		if response.StatusCodeSuccess() {
-			tokenData := new(auth.TokenData)
-			jsonErr := json.Unmarshal(response.Body, &tokenData)
-			if jsonErr != nil {
-				return nil, jsonErr
-			}
+		for _, setting := range app_settings.TokenCredentials {
+			entry := getTokenCredentialEntry(setting.Id)
 
-			return tokenData, nil
+			tokenData, refreshAt := entry.getToken()
+			if tokenData != nil && time.Now().After(refreshAt) {
+				entry.refresh(context.Background(), tokenData)
+			}
 		}
+	}
+}
 
-		return nil, fmt.Errorf("Can't get jwtToken response_status_code: %v settings id %v", response.StatusCode, setting.Id)
+func authenticateClientCredentials(ctx context.Context, setting *credential.TokenCredentialSetting) (*auth.TokenData, error) {
+	data := url.Values{}
+	data.Set("grant_type", "client_credentials")
+
+	if len(strings.TrimSpace(setting.ClientCredential.Audiance)) > 0 {
+		data.Set("audience", setting.ClientCredential.Audiance)
 	}
+
+	if len(strings.TrimSpace(setting.ClientCredential.Scope)) > 0 {
+		data.Set("scope", setting.ClientCredential.Scope)
+	}
+
+	if err := setClientAuthentication(data, setting); err != nil {
+		return nil, err
+	}
+
+	return postTokenRequest(ctx, setting, data)
+}
+
+func basicCredentials(ctx context.Context, setting *credential.TokenCredentialSetting) (*auth.TokenData, error) {
+	data := url.Values{}
+	data.Set("grant_type", "client_credentials")
+
+	return postTokenRequest(ctx, setting, data)
 }
 
-func customAuthentication(setting *credential.TokenCredentialSetting) (*auth.TokenData, error) {
+func customAuthentication(ctx context.Context, setting *credential.TokenCredentialSetting) (*auth.TokenData, error) {
 	request := new(client.HttpClientRequestData)
 	isFormUrlencoded := false
 	if len(setting.Custom.RequestHeaders) > 0 {
@@ -175,7 +392,6 @@ func customAuthentication(setting *credential.TokenCredentialSetting) (*auth.Tok
 	request.Method = string(setting.Custom.Method)
 	request.Url = setting.AuthEndpoint
 
-	ctx := context.Background()
 	response, err := client.HttpClientDo(ctx, request)
 
 	if err != nil {
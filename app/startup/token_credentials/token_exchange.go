@@ -0,0 +1,101 @@
+package token_credentials
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+	"wrench/app/auth"
+	"wrench/app/manifest/action_settings/http_settings"
+	"wrench/app/manifest/types"
+	"wrench/app/stores"
+)
+
+const tokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+const accessTokenType = "urn:ietf:params:oauth:token-type:access_token"
+
+// tokenExchangeSafety discards the cached token a bit before it expires.
+const tokenExchangeSafety = 30 * time.Second
+
+type exchangedToken struct {
+	AccessToken string `json:"accessToken"`
+	TokenType   string `json:"tokenType"`
+}
+
+// ExchangeToken trades the caller token for a downstream token (RFC 8693). The result is cached per
+// caller token until it expires or the caller token expires (subjectExpiresAt), whichever comes first.
+// The key is the hash of the whole token, a claim like sub could be forged in a token not verified.
+func ExchangeToken(ctx context.Context, callerToken *http_settings.CallerTokenSettings, subjectToken string, subjectExpiresAt time.Time) (*auth.TokenData, error) {
+	entry := getTokenCredentialEntry(callerToken.TokenCredentialId)
+	if entry == nil {
+		return nil, fmt.Errorf("tokenCredential %v not found", callerToken.TokenCredentialId)
+	}
+
+	store, err := stores.GetKeyValueStore("tokenExchange:"+callerToken.TokenCredentialId, types.BackendTypeMemory, "", callerToken.MaxEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	subjectTokenHash := sha256.Sum256([]byte(subjectToken))
+	cacheKeyHash := sha256.Sum256([]byte(fmt.Sprintf("%x|%v|%v|%v", subjectTokenHash, callerToken.Audience, callerToken.Resource, callerToken.Scope)))
+	cacheKey := hex.EncodeToString(cacheKeyHash[:])
+
+	if cached, found, err := store.Get(ctx, cacheKey); err == nil && found {
+		var token exchangedToken
+		if json.Unmarshal(cached, &token) == nil {
+			return &auth.TokenData{AccessToken: token.AccessToken, TokenType: token.TokenType}, nil
+		}
+	}
+
+	data := url.Values{}
+	data.Set("grant_type", tokenExchangeGrantType)
+	data.Set("subject_token", subjectToken)
+	data.Set("subject_token_type", accessTokenType)
+	data.Set("requested_token_type", callerToken.GetRequestedTokenType())
+
+	if len(callerToken.Audience) > 0 {
+		data.Set("audience", callerToken.Audience)
+	}
+
+	if len(callerToken.Resource) > 0 {
+		data.Set("resource", callerToken.Resource)
+	}
+
+	if len(callerToken.Scope) > 0 {
+		data.Set("scope", callerToken.Scope)
+	}
+
+	if err := setClientAuthentication(data, entry.setting); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	tokenData, err := postTokenRequest(ctx, entry.setting, data)
+	recordTokenCredentialMetric(ctx, callerToken.TokenCredentialId, time.Since(start), err)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokenData.TokenType) == 0 || tokenData.TokenType == "N_A" {
+		tokenData.TokenType = "Bearer"
+	}
+
+	tokenData.LoadJwtPayload()
+	tokenData.LoadExpiresAt(false)
+
+	expiresAt := tokenData.ExpiresAt
+	if !subjectExpiresAt.IsZero() && subjectExpiresAt.Before(expiresAt) {
+		expiresAt = subjectExpiresAt
+	}
+
+	if ttl := time.Until(expiresAt) - tokenExchangeSafety; ttl > 0 {
+		if cached, err := json.Marshal(exchangedToken{AccessToken: tokenData.AccessToken, TokenType: tokenData.TokenType}); err == nil {
+			store.Set(ctx, cacheKey, cached, ttl)
+		}
+	}
+
+	return tokenData, nil
+}
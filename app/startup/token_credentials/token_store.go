@@ -0,0 +1,190 @@
+package token_credentials
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"wrench/app"
+	"wrench/app/auth"
+	"wrench/app/cross_funcs"
+	"wrench/app/manifest/types"
+	"wrench/app/manifest_cross_funcs"
+	"wrench/app/stores"
+)
+
+const sharedTokenWaitTries = 4
+const sharedTokenWaitDelay = 500 * time.Millisecond
+
+// sharedTokenRecord is what the replicas share, the refresh token goes together because
+// servers rotating it invalidate the one the other replicas know.
+type sharedTokenRecord struct {
+	TokenData    *auth.TokenData `json:"tokenData"`
+	ExpiresAt    time.Time       `json:"expiresAt"`
+	RefreshAt    time.Time       `json:"refreshAt"`
+	RefreshToken string          `json:"refreshToken"`
+}
+
+func (record *sharedTokenRecord) isUsable(stale *auth.TokenData) bool {
+	if record == nil || record.TokenData == nil || !time.Now().Before(record.RefreshAt) {
+		return false
+	}
+
+	return stale == nil || record.TokenData.AccessToken != stale.AccessToken
+}
+
+// fetchShared reads the token refreshed by any replica and only refreshes it holding the credential lock.
+func (entry *tokenCredentialEntry) fetchShared(ctx context.Context, stale *auth.TokenData) (*auth.TokenData, error) {
+	setting := entry.setting
+	storeKey := getSharedTokenKey(setting.Id)
+
+	record, err := entry.loadSharedToken(ctx, storeKey)
+	if err != nil {
+		app.LogError2(fmt.Sprintf("tokenCredential %v error to read the shared token", setting.Id), err)
+	}
+	if record.isUsable(stale) {
+		return entry.useSharedToken(record), nil
+	}
+
+	locker := stores.GetLocker(setting.Store.RedisConnectionId)
+	unlock, err := locker.Lock(ctx, storeKey+":lock")
+	if errors.Is(err, stores.ErrLockNotAcquired) {
+		// another replica is refreshing, fetching here too would send a refresh token it may have rotated
+		if record := entry.waitSharedToken(ctx, storeKey, stale); record != nil {
+			return entry.useSharedToken(record), nil
+		}
+		if stale != nil && !stale.IsExpired(0) {
+			return stale, nil
+		}
+		unlock, err = locker.Lock(ctx, storeKey+":lock")
+	}
+	if err != nil {
+		if errors.Is(err, stores.ErrLockNotAcquired) {
+			return nil, fmt.Errorf("tokenCredential %v is being refreshed by another replica", setting.Id)
+		}
+
+		// only without redis the token is fetched without the lock
+		app.LogError2(fmt.Sprintf("tokenCredential %v error to lock the shared token", setting.Id), err)
+		return entry.fetch(ctx)
+	}
+	defer unlock()
+
+	if record, _ = entry.loadSharedToken(ctx, storeKey); record.isUsable(stale) {
+		return entry.useSharedToken(record), nil
+	}
+
+	if record != nil && len(record.RefreshToken) > 0 {
+		entry.stateMutex.Lock()
+		entry.refreshToken = record.RefreshToken
+		entry.stateMutex.Unlock()
+	}
+
+	tokenData, err := entry.fetch(ctx)
+	if err != nil || tokenData == stale {
+		return tokenData, err
+	}
+
+	if err := entry.saveSharedToken(ctx, storeKey); err != nil {
+		app.LogError2(fmt.Sprintf("tokenCredential %v error to save the shared token", setting.Id), err)
+	}
+
+	return tokenData, nil
+}
+
+func (entry *tokenCredentialEntry) waitSharedToken(ctx context.Context, storeKey string, stale *auth.TokenData) *sharedTokenRecord {
+	for i := 0; i < sharedTokenWaitTries; i++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(sharedTokenWaitDelay):
+		}
+
+		if record, _ := entry.loadSharedToken(ctx, storeKey); record.isUsable(stale) {
+			return record
+		}
+	}
+	return nil
+}
+
+func (entry *tokenCredentialEntry) useSharedToken(record *sharedTokenRecord) *auth.TokenData {
+	tokenData := record.TokenData
+	if !entry.setting.IsOpaque {
+		tokenData.LoadJwtPayload()
+	}
+	tokenData.ExpiresAt = record.ExpiresAt
+
+	entry.stateMutex.Lock()
+	defer entry.stateMutex.Unlock()
+	entry.setToken(tokenData, record.RefreshAt, record.RefreshToken)
+	return tokenData
+}
+
+func (entry *tokenCredentialEntry) loadSharedToken(ctx context.Context, storeKey string) (*sharedTokenRecord, error) {
+	store, err := getSharedTokenStore(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, found, err := store.Get(ctx, storeKey)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	plaintext, err := cross_funcs.DecryptAesGcm(entry.setting.Store.GetEncryptionKey(), encrypted, []byte(storeKey))
+	if err != nil {
+		return nil, err
+	}
+
+	record := new(sharedTokenRecord)
+	if err := json.Unmarshal(plaintext, record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+func (entry *tokenCredentialEntry) saveSharedToken(ctx context.Context, storeKey string) error {
+	store, err := getSharedTokenStore(entry)
+	if err != nil {
+		return err
+	}
+
+	entry.stateMutex.RLock()
+	record := &sharedTokenRecord{
+		TokenData:    entry.tokenData,
+		ExpiresAt:    entry.tokenData.ExpiresAt,
+		RefreshAt:    entry.refreshAt,
+		RefreshToken: entry.refreshToken,
+	}
+	entry.stateMutex.RUnlock()
+
+	// keep the refresh token while it's valid, it's still useful after the access token expires
+	ttl := time.Until(record.ExpiresAt)
+	if refreshExpiresIn := time.Duration(record.TokenData.RefreshExpiresIn) * time.Second; len(record.RefreshToken) > 0 && refreshExpiresIn > ttl {
+		ttl = refreshExpiresIn
+	}
+	if ttl <= 0 {
+		return nil
+	}
+
+	plaintext, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := cross_funcs.EncryptAesGcm(entry.setting.Store.GetEncryptionKey(), plaintext, []byte(storeKey))
+	if err != nil {
+		return err
+	}
+
+	return store.Set(ctx, storeKey, encrypted, ttl)
+}
+
+func getSharedTokenStore(entry *tokenCredentialEntry) (stores.KeyValueStore, error) {
+	return stores.GetKeyValueStore("tokenCredentials", types.BackendTypeRedis, entry.setting.Store.RedisConnectionId, 0)
+}
+
+func getSharedTokenKey(tokenCredentialId string) string {
+	return fmt.Sprintf("%v:tokenCredentials:%v", manifest_cross_funcs.GetService().Name, tokenCredentialId)
+}
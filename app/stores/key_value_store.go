@@ -0,0 +1,66 @@
+package stores
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"wrench/app/manifest/types"
+	"wrench/app/startup/connections"
+)
+
+const defaultMemoryMaxEntries = 10000
+
+type KeyValueStore interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	Delete(ctx context.Context, key string) error
+	// DeleteIfValue deletes the key only while it still holds value, e.g. a lock owned by the caller.
+	DeleteIfValue(ctx context.Context, key string, value []byte) (bool, error)
+}
+
+var keyValueStores = make(map[string]KeyValueStore)
+var keyValueStoresMutex sync.Mutex
+
+func GetKeyValueStore(name string, backend types.BackendType, redisConnectionId string, maxEntries int) (KeyValueStore, error) {
+	keyValueStoresMutex.Lock()
+	defer keyValueStoresMutex.Unlock()
+
+	storeKey := fmt.Sprintf("%v:%v:%v", backend, redisConnectionId, name)
+	store := keyValueStores[storeKey]
+
+	if store == nil {
+		if backend == types.BackendTypeMemory {
+			store = newMemoryKeyValueStore(maxEntries)
+		} else {
+			uClient, err := connections.GetRedisConnection(redisConnectionId)
+			if err != nil {
+				return nil, err
+			}
+			store = newRedisKeyValueStore(uClient)
+		}
+
+		keyValueStores[storeKey] = store
+	}
+
+	return store, nil
+}
+
+// ResetRedisStores drops the stores and rate limiters of the redis connection, they are created
+// again with the current client on the next use.
+func ResetRedisStores(redisConnectionId string) {
+	keyValueStoresMutex.Lock()
+	storePrefix := fmt.Sprintf("%v:%v:", types.BackendTypeRedis, redisConnectionId)
+	for storeKey := range keyValueStores {
+		if strings.HasPrefix(storeKey, storePrefix) {
+			delete(keyValueStores, storeKey)
+		}
+	}
+	keyValueStoresMutex.Unlock()
+
+	rateLimitersMutex.Lock()
+	delete(rateLimiters, string(types.BackendTypeRedis)+":"+redisConnectionId)
+	rateLimitersMutex.Unlock()
+}
@@ -0,0 +1,79 @@
+package stores
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+	"wrench/app/cross_funcs"
+
+	"github.com/go-redsync/redsync/v4"
+)
+
+const lockTries = 5
+const lockRetryDelay = 500 * time.Millisecond
+const lockExpiry = 20 * time.Second
+
+var ErrLockNotAcquired = errors.New("lock not acquired")
+
+type Unlock func() error
+
+type Locker interface {
+	Lock(ctx context.Context, key string) (Unlock, error)
+}
+
+var lockers = make(map[string]Locker)
+var lockersMutex sync.Mutex
+
+func GetLocker(redisConnectionId string) Locker {
+	lockersMutex.Lock()
+	defer lockersMutex.Unlock()
+
+	locker := lockers[redisConnectionId]
+
+	if locker == nil {
+		locker = &redisLocker{redisConnectionId: redisConnectionId}
+		lockers[redisConnectionId] = locker
+	}
+
+	return locker
+}
+
+type redisLocker struct {
+	redisConnectionId string
+}
+
+func (locker *redisLocker) Lock(ctx context.Context, key string) (Unlock, error) {
+	rd := cross_funcs.GetRedsyncInstance(locker.redisConnectionId)
+
+	mutex := rd.NewMutex(key,
+		redsync.WithTries(lockTries),
+		redsync.WithRetryDelay(lockRetryDelay),
+		redsync.WithExpiry(lockExpiry),
+	)
+
+	if err := mutex.LockContext(ctx); err != nil {
+		if isLockTaken(err) {
+			return nil, ErrLockNotAcquired
+		}
+		return nil, err
+	}
+
+	return func() error {
+		ok, err := mutex.Unlock()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrLockNotAcquired
+		}
+		return nil
+	}, nil
+}
+
+// isLockTaken the lock is held by someone else, other errors are failures talking to redis
+func isLockTaken(err error) bool {
+	var taken *redsync.ErrTaken
+	var nodeTaken *redsync.ErrNodeTaken
+	return errors.Is(err, redsync.ErrFailed) || errors.As(err, &taken) || errors.As(err, &nodeTaken)
+}
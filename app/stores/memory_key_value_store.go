@@ -0,0 +1,151 @@
+package stores
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	key       string
+	value     interface{}
+	expiresAt time.Time
+}
+
+func (entry *memoryEntry) isExpired(now time.Time) bool {
+	return !entry.expiresAt.IsZero() && now.After(entry.expiresAt)
+}
+
+// memoryLru is a size bounded LRU where every entry carries its own TTL.
+type memoryLru struct {
+	mutex      sync.Mutex
+	maxEntries int
+	items      map[string]*list.Element
+	order      *list.List
+}
+
+func newMemoryLru(maxEntries int) *memoryLru {
+	if maxEntries <= 0 {
+		maxEntries = defaultMemoryMaxEntries
+	}
+
+	return &memoryLru{
+		maxEntries: maxEntries,
+		items:      make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+func (lru *memoryLru) get(key string, now time.Time) (interface{}, bool) {
+	element, ok := lru.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*memoryEntry)
+	if entry.isExpired(now) {
+		lru.remove(element)
+		return nil, false
+	}
+
+	lru.order.MoveToFront(element)
+	return entry.value, true
+}
+
+func (lru *memoryLru) set(key string, value interface{}, ttl time.Duration, now time.Time) {
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = now.Add(ttl)
+	}
+
+	if element, ok := lru.items[key]; ok {
+		entry := element.Value.(*memoryEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		lru.order.MoveToFront(element)
+		return
+	}
+
+	element := lru.order.PushFront(&memoryEntry{key: key, value: value, expiresAt: expiresAt})
+	lru.items[key] = element
+
+	for lru.order.Len() > lru.maxEntries {
+		lru.remove(lru.order.Back())
+	}
+}
+
+func (lru *memoryLru) delete(key string) {
+	if element, ok := lru.items[key]; ok {
+		lru.remove(element)
+	}
+}
+
+func (lru *memoryLru) remove(element *list.Element) {
+	entry := element.Value.(*memoryEntry)
+	delete(lru.items, entry.key)
+	lru.order.Remove(element)
+}
+
+type memoryKeyValueStore struct {
+	lru *memoryLru
+}
+
+func newMemoryKeyValueStore(maxEntries int) *memoryKeyValueStore {
+	return &memoryKeyValueStore{lru: newMemoryLru(maxEntries)}
+}
+
+func (store *memoryKeyValueStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	store.lru.mutex.Lock()
+	defer store.lru.mutex.Unlock()
+
+	value, ok := store.lru.get(key, time.Now())
+	if !ok {
+		return nil, false, nil
+	}
+
+	return value.([]byte), true, nil
+}
+
+func (store *memoryKeyValueStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	store.lru.mutex.Lock()
+	defer store.lru.mutex.Unlock()
+
+	store.lru.set(key, value, ttl, time.Now())
+	return nil
+}
+
+func (store *memoryKeyValueStore) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	store.lru.mutex.Lock()
+	defer store.lru.mutex.Unlock()
+
+	now := time.Now()
+	if _, ok := store.lru.get(key, now); ok {
+		return false, nil
+	}
+
+	store.lru.set(key, value, ttl, now)
+	return true, nil
+}
+
+func (store *memoryKeyValueStore) Delete(ctx context.Context, key string) error {
+	store.lru.mutex.Lock()
+	defer store.lru.mutex.Unlock()
+
+	store.lru.delete(key)
+	return nil
+}
+
+func (store *memoryKeyValueStore) DeleteIfValue(ctx context.Context, key string, value []byte) (bool, error) {
+	store.lru.mutex.Lock()
+	defer store.lru.mutex.Unlock()
+
+	current, ok := store.lru.get(key, time.Now())
+	if !ok || !bytes.Equal(current.([]byte), value) {
+		return false, nil
+	}
+
+	store.lru.delete(key)
+	return true, nil
+}
@@ -0,0 +1,149 @@
+package stores
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// memoryRateLimiter keeps the same algorithms as the redis backend in an in-process LRU.
+type memoryRateLimiter struct {
+	lru *memoryLru
+}
+
+func newMemoryRateLimiter(maxEntries int) *memoryRateLimiter {
+	return &memoryRateLimiter{lru: newMemoryLru(maxEntries)}
+}
+
+func (limiter *memoryRateLimiter) Allow(ctx context.Context, key string, limit RateLimit) (*RateLimitResult, error) {
+	limiter.lru.mutex.Lock()
+	defer limiter.lru.mutex.Unlock()
+
+	now := time.Now()
+	burst := limit.getBurst()
+	emissionInterval := limit.Period / time.Duration(limit.Rate)
+	burstOffset := emissionInterval * time.Duration(burst)
+
+	tat := now
+	if value, ok := limiter.lru.get(key, now); ok {
+		if storedTat := value.(time.Time); storedTat.After(now) {
+			tat = storedTat
+		}
+	}
+
+	newTat := tat.Add(emissionInterval)
+	allowAt := newTat.Add(-burstOffset)
+
+	if diff := now.Sub(allowAt); diff < 0 {
+		resetAfter := tat.Sub(now)
+		return &RateLimitResult{
+			Allowed:    false,
+			Limit:      limit.Rate,
+			Remaining:  0,
+			RetryAfter: -diff,
+			ResetAfter: resetAfter,
+		}, nil
+	}
+
+	resetAfter := newTat.Sub(now)
+	limiter.lru.set(key, newTat, resetAfter, now)
+
+	remaining := int((burstOffset - resetAfter) / emissionInterval)
+
+	return &RateLimitResult{
+		Allowed:    true,
+		Limit:      limit.Rate,
+		Remaining:  remaining,
+		RetryAfter: -1,
+		ResetAfter: resetAfter,
+		refund: func(ctx context.Context) error {
+			limiter.refundTat(key, emissionInterval)
+			return nil
+		},
+	}, nil
+}
+
+func (limiter *memoryRateLimiter) AllowSlidingWindow(ctx context.Context, key string, limit RateLimit) (*RateLimitResult, error) {
+	limiter.lru.mutex.Lock()
+	defer limiter.lru.mutex.Unlock()
+
+	now := time.Now()
+	windowStart, elapsed := slidingWindowStart(now, limit.Period)
+	windowIndex := windowStart.UnixMilli() / limit.Period.Milliseconds()
+
+	currentKey := key + ":sw:" + strconv.FormatInt(windowIndex, 10)
+	previousKey := key + ":sw:" + strconv.FormatInt(windowIndex-1, 10)
+
+	current := limiter.getCounter(currentKey, now)
+	previous := limiter.getCounter(previousKey, now)
+
+	weight := slidingWindowWeight(elapsed, limit.Period)
+	allowed := int(float64(previous)*weight)+current < limit.Rate
+
+	if allowed {
+		current++
+		limiter.lru.set(currentKey, current, 2*limit.Period-elapsed, now)
+	}
+
+	result := slidingWindowResult(limit, allowed, current, previous, elapsed)
+	result.refund = limiter.refundCounter(currentKey)
+	return result, nil
+}
+
+func (limiter *memoryRateLimiter) AllowQuota(ctx context.Context, key string, limit int, resetAt time.Time) (*RateLimitResult, error) {
+	limiter.lru.mutex.Lock()
+	defer limiter.lru.mutex.Unlock()
+
+	now := time.Now()
+	current := limiter.getCounter(key, now)
+	allowed := current < limit
+
+	if allowed {
+		current++
+		limiter.lru.set(key, current, resetAt.Sub(now), now)
+	}
+
+	result := quotaResult(limit, allowed, current, resetAt, now)
+	result.refund = limiter.refundCounter(key)
+	return result, nil
+}
+
+func (limiter *memoryRateLimiter) refundTat(key string, emissionInterval time.Duration) {
+	limiter.lru.mutex.Lock()
+	defer limiter.lru.mutex.Unlock()
+
+	now := time.Now()
+	value, ok := limiter.lru.get(key, now)
+	if !ok {
+		return
+	}
+
+	if tat := value.(time.Time).Add(-emissionInterval); tat.After(now) {
+		limiter.lru.set(key, tat, tat.Sub(now), now)
+	} else {
+		limiter.lru.delete(key)
+	}
+}
+
+// refundCounter decrements the counter keeping its expiration
+func (limiter *memoryRateLimiter) refundCounter(key string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		limiter.lru.mutex.Lock()
+		defer limiter.lru.mutex.Unlock()
+
+		if element, ok := limiter.lru.items[key]; ok {
+			entry := element.Value.(*memoryEntry)
+			if current := entry.value.(int); current > 0 {
+				entry.value = current - 1
+			}
+		}
+		return nil
+	}
+}
+
+func (limiter *memoryRateLimiter) getCounter(key string, now time.Time) int {
+	if value, ok := limiter.lru.get(key, now); ok {
+		return value.(int)
+	}
+	return 0
+}
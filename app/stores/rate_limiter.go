@@ -0,0 +1,156 @@
+package stores
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+	"wrench/app/manifest/types"
+	"wrench/app/startup/connections"
+
+	"github.com/go-redis/redis_rate/v10"
+)
+
+type RateLimit struct {
+	Rate   int
+	Burst  int
+	Period time.Duration
+}
+
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+	ResetAfter time.Duration
+
+	refund func(ctx context.Context) error
+}
+
+// Refund gives back the request counted by an allowed result, used when a later limit rejects the request.
+func (result *RateLimitResult) Refund(ctx context.Context) error {
+	if result == nil || !result.Allowed || result.refund == nil {
+		return nil
+	}
+	return result.refund(ctx)
+}
+
+// MostRestrictive returns the result with the fewest remaining requests, its refund gives back every result.
+func MostRestrictive(results []*RateLimitResult) *RateLimitResult {
+	mostRestrictive := *results[0]
+	for _, result := range results[1:] {
+		if result.Remaining < mostRestrictive.Remaining {
+			mostRestrictive = *result
+		}
+	}
+
+	mostRestrictive.refund = func(ctx context.Context) error {
+		var errs []error
+		for _, result := range results {
+			if err := result.Refund(ctx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+
+	return &mostRestrictive
+}
+
+type RateLimiter interface {
+	// Allow applies a GCRA token bucket.
+	Allow(ctx context.Context, key string, limit RateLimit) (*RateLimitResult, error)
+	// AllowSlidingWindow applies a sliding window counter weighting the previous window.
+	AllowSlidingWindow(ctx context.Context, key string, limit RateLimit) (*RateLimitResult, error)
+	// AllowQuota applies a fixed window counter that expires at resetAt.
+	AllowQuota(ctx context.Context, key string, limit int, resetAt time.Time) (*RateLimitResult, error)
+}
+
+var rateLimiters = make(map[string]RateLimiter)
+var rateLimitersMutex sync.Mutex
+
+func GetRateLimiter(backend types.BackendType, redisConnectionId string, maxEntries int) (RateLimiter, error) {
+	rateLimitersMutex.Lock()
+	defer rateLimitersMutex.Unlock()
+
+	limiterKey := string(backend) + ":" + redisConnectionId
+	limiter := rateLimiters[limiterKey]
+
+	if limiter == nil {
+		if backend == types.BackendTypeMemory {
+			limiter = newMemoryRateLimiter(maxEntries)
+		} else {
+			uClient, err := connections.GetRedisConnection(redisConnectionId)
+			if err != nil {
+				return nil, err
+			}
+			limiter = &redisRateLimiter{client: uClient, limiter: redis_rate.NewLimiter(uClient)}
+		}
+
+		rateLimiters[limiterKey] = limiter
+	}
+
+	return limiter, nil
+}
+
+func (limit RateLimit) getBurst() int {
+	if limit.Burst > 0 {
+		return limit.Burst
+	}
+	return limit.Rate
+}
+
+func slidingWindowStart(now time.Time, period time.Duration) (time.Time, time.Duration) {
+	windowStart := now.Truncate(period)
+	return windowStart, now.Sub(windowStart)
+}
+
+func slidingWindowWeight(elapsed time.Duration, period time.Duration) float64 {
+	return float64(period-elapsed) / float64(period)
+}
+
+func slidingWindowResult(limit RateLimit, allowed bool, current int, previous int, elapsed time.Duration) *RateLimitResult {
+	weight := slidingWindowWeight(elapsed, limit.Period)
+	estimated := int(math.Floor(float64(previous)*weight)) + current
+	resetAfter := limit.Period - elapsed
+
+	result := &RateLimitResult{
+		Allowed:    allowed,
+		Limit:      limit.Rate,
+		Remaining:  max(limit.Rate-estimated, 0),
+		RetryAfter: -1,
+		ResetAfter: resetAfter,
+	}
+
+	if !allowed {
+		if current >= limit.Rate || previous == 0 {
+			result.RetryAfter = resetAfter
+		} else {
+			// time until the weighted previous window leaves room for one more request
+			targetWeight := float64(limit.Rate-current) / float64(previous)
+			targetElapsed := time.Duration((1 - targetWeight) * float64(limit.Period))
+			result.RetryAfter = max(targetElapsed-elapsed, time.Millisecond)
+		}
+	}
+
+	return result
+}
+
+func quotaResult(limit int, allowed bool, current int, resetAt time.Time, now time.Time) *RateLimitResult {
+	resetAfter := resetAt.Sub(now)
+
+	result := &RateLimitResult{
+		Allowed:    allowed,
+		Limit:      limit,
+		Remaining:  max(limit-current, 0),
+		RetryAfter: -1,
+		ResetAfter: resetAfter,
+	}
+
+	if !allowed {
+		result.RetryAfter = resetAfter
+	}
+
+	return result
+}
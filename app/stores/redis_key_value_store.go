@@ -0,0 +1,51 @@
+package stores
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var deleteIfValueScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+type redisKeyValueStore struct {
+	client redis.UniversalClient
+}
+
+func newRedisKeyValueStore(client redis.UniversalClient) *redisKeyValueStore {
+	return &redisKeyValueStore{client: client}
+}
+
+func (store *redisKeyValueStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	val, err := store.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	return val, true, nil
+}
+
+func (store *redisKeyValueStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return store.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (store *redisKeyValueStore) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return store.client.SetNX(ctx, key, value, ttl).Result()
+}
+
+func (store *redisKeyValueStore) Delete(ctx context.Context, key string) error {
+	return store.client.Del(ctx, key).Err()
+}
+
+func (store *redisKeyValueStore) DeleteIfValue(ctx context.Context, key string, value []byte) (bool, error) {
+	deleted, err := deleteIfValueScript.Run(ctx, store.client, []string{key}, value).Int()
+	return deleted == 1, err
+}
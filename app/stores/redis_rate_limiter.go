@@ -0,0 +1,115 @@
+package stores
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis_rate/v10"
+	"github.com/redis/go-redis/v9"
+)
+
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local weight = tonumber(ARGV[2])
+local ttl = tonumber(ARGV[3])
+local current = tonumber(redis.call("GET", KEYS[1]) or "0")
+local previous = tonumber(redis.call("GET", KEYS[2]) or "0")
+if math.floor(previous * weight) + current >= limit then
+	return {0, current, previous}
+end
+current = redis.call("INCR", KEYS[1])
+redis.call("PEXPIRE", KEYS[1], ttl)
+return {1, current, previous}
+`)
+
+var quotaScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local current = tonumber(redis.call("GET", KEYS[1]) or "0")
+if current >= limit then
+	return {0, current}
+end
+current = redis.call("INCR", KEYS[1])
+redis.call("PEXPIREAT", KEYS[1], ARGV[2])
+return {1, current}
+`)
+
+// refundScript decrements a counter keeping its expiration, never below zero
+var refundScript = redis.NewScript(`
+local current = tonumber(redis.call("GET", KEYS[1]) or "0")
+if current > 0 then
+	redis.call("DECR", KEYS[1])
+end
+return current
+`)
+
+type redisRateLimiter struct {
+	client  redis.UniversalClient
+	limiter *redis_rate.Limiter
+}
+
+func (limiter *redisRateLimiter) Allow(ctx context.Context, key string, limit RateLimit) (*RateLimitResult, error) {
+	redisLimit := redis_rate.Limit{
+		Rate:   limit.Rate,
+		Burst:  limit.getBurst(),
+		Period: limit.Period,
+	}
+
+	res, err := limiter.limiter.Allow(ctx, key, redisLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RateLimitResult{
+		Allowed:    res.Allowed > 0,
+		Limit:      res.Limit.Rate,
+		Remaining:  res.Remaining,
+		RetryAfter: res.RetryAfter,
+		ResetAfter: res.ResetAfter,
+		refund: func(ctx context.Context) error {
+			// a negative cost moves the GCRA theoretical arrival time back by one request
+			_, err := limiter.limiter.AllowN(ctx, key, redisLimit, -1)
+			return err
+		},
+	}, nil
+}
+
+func (limiter *redisRateLimiter) AllowSlidingWindow(ctx context.Context, key string, limit RateLimit) (*RateLimitResult, error) {
+	windowStart, elapsed := slidingWindowStart(time.Now(), limit.Period)
+	windowIndex := windowStart.UnixMilli() / limit.Period.Milliseconds()
+
+	// hash tag keeps both windows in the same slot for redis cluster
+	keys := []string{
+		"{" + key + "}:sw:" + strconv.FormatInt(windowIndex, 10),
+		"{" + key + "}:sw:" + strconv.FormatInt(windowIndex-1, 10),
+	}
+
+	weight := slidingWindowWeight(elapsed, limit.Period)
+	ttl := (2 * limit.Period).Milliseconds()
+
+	values, err := slidingWindowScript.Run(ctx, limiter.client, keys, limit.Rate, strconv.FormatFloat(weight, 'f', -1, 64), ttl).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	result := slidingWindowResult(limit, values[0] == 1, int(values[1]), int(values[2]), elapsed)
+	result.refund = limiter.refundCounter(keys[0])
+	return result, nil
+}
+
+func (limiter *redisRateLimiter) AllowQuota(ctx context.Context, key string, limit int, resetAt time.Time) (*RateLimitResult, error) {
+	values, err := quotaScript.Run(ctx, limiter.client, []string{key}, limit, resetAt.UnixMilli()).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	result := quotaResult(limit, values[0] == 1, int(values[1]), resetAt, time.Now())
+	result.refund = limiter.refundCounter(key)
+	return result, nil
+}
+
+func (limiter *redisRateLimiter) refundCounter(key string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return refundScript.Run(ctx, limiter.client, []string{key}).Err()
+	}
+}
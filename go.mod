@@ -4,7 +4,10 @@ go 1.23.0
 
 toolchain go1.23.2
 
-require github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue v1.20.9
+require (
+	github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue v1.20.9
+	github.com/aws/aws-sdk-go-v2/service/ssm v1.56.2
+)
 
 require (
 	github.com/MicahParks/keyfunc v1.9.0 // indirect